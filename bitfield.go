@@ -1,6 +1,11 @@
 package bitfield
 
-import "encoding/hex"
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"math/bits"
+)
 
 // Bitfield provides operations for reading and manipulating bits in group of bytes.
 type Bitfield struct {
@@ -17,14 +22,26 @@ func New(length uint32) *Bitfield {
 // Bytes in b are not copied. Unused bits in last byte are cleared.
 // Panics if b is not big enough to hold "length" bits.
 func NewBytes(b []byte, length uint32) *Bitfield {
+	bf, err := NewBytesSafe(b, length)
+	if err != nil {
+		panic(err)
+	}
+	return bf
+}
+
+// NewBytesSafe is like NewBytes but returns an error instead of panicking
+// when b is not big enough to hold "length" bits. Use this instead of
+// NewBytes when length and b come from untrusted input, such as a
+// bitfield message read off the wire.
+func NewBytesSafe(b []byte, length uint32) (*Bitfield, error) {
 	nBytes, nLastBits := calcSize(length)
 	if uint32(len(b)) < nBytes {
-		panic("not enough bytes in slice for specified length")
+		return nil, errors.New("bitfield: not enough bytes in slice for specified length")
 	}
 	if nLastBits != 0 {
 		b[len(b)-1] &= ^(0xff >> nLastBits)
 	}
-	return &Bitfield{b[:nBytes], length}
+	return &Bitfield{b[:nBytes], length}, nil
 }
 
 // calcSize calculates the number of bytes that is required to store length bits
@@ -126,9 +143,46 @@ var countCache = [256]byte{
 
 // Count returns the count of set bits.
 func (b *Bitfield) Count() uint32 {
+	return countBytes(b.b)
+}
+
+// CountRange returns the count of set bits in [start, end).
+// Panics if start > end or end > b.Len().
+func (b *Bitfield) CountRange(start, end uint32) uint32 {
+	if start > end || end > b.length {
+		panic("index out of bound")
+	}
+	if start == end {
+		return 0
+	}
+	startByte, startBit := divMod32(start, 8)
+	endByte, endBit := divMod32(end, 8)
+	if startByte == endByte {
+		mask := (byte(0xff) >> startBit) &^ (byte(0xff) >> endBit)
+		return uint32(countCache[b.b[startByte]&mask])
+	}
+	var total uint32
+	if startBit != 0 {
+		total += uint32(countCache[b.b[startByte]&(byte(0xff)>>startBit)])
+		startByte++
+	}
+	total += countBytes(b.b[startByte:endByte])
+	if endBit != 0 {
+		total += uint32(countCache[b.b[endByte]&^(byte(0xff)>>endBit)])
+	}
+	return total
+}
+
+// countBytes returns the count of set bits in p, processing 8 bytes at a time
+// via math/bits.OnesCount64 and falling back to countCache for the tail.
+func countBytes(p []byte) uint32 {
 	var total uint32
-	for _, v := range b.b {
-		total += uint32(countCache[v])
+	i := 0
+	for ; i+8 <= len(p); i += 8 {
+		total += uint32(bits.OnesCount64(binary.BigEndian.Uint64(p[i : i+8])))
+	}
+	for ; i < len(p); i++ {
+		total += uint32(countCache[p[i]])
 	}
 	return total
 }
@@ -0,0 +1,55 @@
+package bitfield
+
+import "testing"
+
+func TestCountRange(t *testing.T) {
+	b := New(100)
+	for i := uint32(0); i < 100; i += 3 {
+		b.Set(i)
+	}
+	var want uint32
+	for i := uint32(0); i < 100; i++ {
+		if b.Test(i) {
+			want++
+		}
+	}
+	if got := b.CountRange(0, 100); got != want {
+		t.Fatalf("CountRange(0,100) = %d, want %d", got, want)
+	}
+
+	// Exercise every (start,end) pair, including ones that don't land on
+	// byte boundaries, against a bit-by-bit reference count -- this is the
+	// boundary masking CountRange relies on to avoid counting bits outside
+	// [start,end) in the first/last partial byte.
+	for start := uint32(0); start <= 100; start++ {
+		for end := start; end <= 100; end++ {
+			var want uint32
+			for i := start; i < end; i++ {
+				if b.Test(i) {
+					want++
+				}
+			}
+			if got := b.CountRange(start, end); got != want {
+				t.Fatalf("CountRange(%d,%d) = %d, want %d", start, end, got, want)
+			}
+		}
+	}
+}
+
+func TestCountRangeEmpty(t *testing.T) {
+	b := New(10)
+	b.Set(5)
+	if got := b.CountRange(3, 3); got != 0 {
+		t.Fatalf("CountRange(3,3) = %d, want 0", got)
+	}
+}
+
+func TestCountRangePanicsOnBadBounds(t *testing.T) {
+	b := New(10)
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected panic for start > end")
+		}
+	}()
+	b.CountRange(5, 2)
+}
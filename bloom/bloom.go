@@ -0,0 +1,152 @@
+// Package bloom implements a counting-free Bloom filter backed by a
+// *bitfield.Bitfield, using Kirsch-Mitzenmacher double hashing to derive
+// k bit positions from two 64-bit hashes instead of running k independent
+// hash functions.
+package bloom
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	"github.com/cenkalti/bitfield"
+)
+
+// version is the leading byte of the format Bytes/NewFilterFromBytes use.
+const version = 1
+
+// Filter is a Bloom filter over byte-slice items.
+type Filter struct {
+	bits *bitfield.Bitfield
+	k    uint32
+}
+
+// NewFilter returns a Filter sized for n items at a target false-positive
+// rate p, using the standard estimates m = -n*ln(p)/(ln 2)^2 bits and
+// k = round((m/n)*ln 2) hash rounds.
+func NewFilter(n uint32, p float64) *Filter {
+	if n == 0 {
+		n = 1
+	}
+	m := uint32(math.Ceil(-float64(n) * math.Log(p) / (math.Ln2 * math.Ln2)))
+	if m == 0 {
+		m = 1
+	}
+	k := uint32(math.Round(float64(m) / float64(n) * math.Ln2))
+	if k == 0 {
+		k = 1
+	}
+	return NewFilterFromEstimates(m, k)
+}
+
+// NewFilterFromEstimates returns a Filter with an explicit number of bits m
+// and hash rounds k. m is clamped to at least 1, since Add/Contains use it
+// as a modulus.
+func NewFilterFromEstimates(m, k uint32) *Filter {
+	if m == 0 {
+		m = 1
+	}
+	if k == 0 {
+		k = 1
+	}
+	return &Filter{bits: bitfield.New(m), k: k}
+}
+
+// Add adds data to the filter.
+func (f *Filter) Add(data []byte) {
+	h1, h2 := hash1(data), hash2(data)
+	m := uint64(f.bits.Len())
+	for i := uint64(0); i < uint64(f.k); i++ {
+		f.bits.Set(uint32((h1 + i*h2) % m))
+	}
+}
+
+// Contains reports whether data may have been added to the filter. False
+// positives are possible; false negatives are not.
+func (f *Filter) Contains(data []byte) bool {
+	h1, h2 := hash1(data), hash2(data)
+	m := uint64(f.bits.Len())
+	for i := uint64(0); i < uint64(f.k); i++ {
+		if !f.bits.Test(uint32((h1 + i*h2) % m)) {
+			return false
+		}
+	}
+	return true
+}
+
+// ApproxCount estimates the number of distinct items added to the filter,
+// using the Swamidass/Baldi estimator -m/k * ln(1 - X/m), where X is the
+// number of set bits.
+func (f *Filter) ApproxCount() uint64 {
+	m := float64(f.bits.Len())
+	x := float64(f.bits.Count())
+	if x >= m {
+		x = m - 1 // avoid ln(0); the estimate saturates once the filter is full
+	}
+	return uint64(math.Round(-m / float64(f.k) * math.Log(1-x/m)))
+}
+
+// Union ORs other's bits into f, so that f.Contains reports true for
+// anything added to either filter. Returns an error if f and other were
+// not built with matching m and k.
+func (f *Filter) Union(other *Filter) error {
+	if err := f.checkCompatible(other); err != nil {
+		return err
+	}
+	f.bits.InPlaceUnion(other.bits)
+	return nil
+}
+
+// Intersect ANDs other's bits into f. The result may have a higher
+// false-positive rate than either input, since it approximates the
+// intersection of the two item sets. Returns an error if f and other were
+// not built with matching m and k.
+func (f *Filter) Intersect(other *Filter) error {
+	if err := f.checkCompatible(other); err != nil {
+		return err
+	}
+	f.bits.InPlaceIntersection(other.bits)
+	return nil
+}
+
+func (f *Filter) checkCompatible(other *Filter) error {
+	if f.bits.Len() != other.bits.Len() || f.k != other.k {
+		return fmt.Errorf("bloom: mismatched filter parameters (m=%d,k=%d vs m=%d,k=%d)", f.bits.Len(), f.k, other.bits.Len(), other.k)
+	}
+	return nil
+}
+
+// Bytes returns the serialized form of f: a version byte, then m and k as
+// big-endian uint32s, then the underlying Bitfield's bytes. A Filter is
+// thus transparently backed by, and inspectable as, a bitfield.Bitfield.
+func (f *Filter) Bytes() []byte {
+	bb := f.bits.Bytes()
+	buf := make([]byte, 9+len(bb))
+	buf[0] = version
+	binary.BigEndian.PutUint32(buf[1:5], f.bits.Len())
+	binary.BigEndian.PutUint32(buf[5:9], f.k)
+	copy(buf[9:], bb)
+	return buf
+}
+
+// NewFilterFromBytes reconstructs a Filter previously serialized with Bytes.
+func NewFilterFromBytes(b []byte) (*Filter, error) {
+	if len(b) < 9 {
+		return nil, fmt.Errorf("bloom: short input")
+	}
+	if b[0] != version {
+		return nil, fmt.Errorf("bloom: unsupported version %d", b[0])
+	}
+	m := binary.BigEndian.Uint32(b[1:5])
+	k := binary.BigEndian.Uint32(b[5:9])
+	if m == 0 || k == 0 {
+		return nil, fmt.Errorf("bloom: invalid filter parameters (m=%d,k=%d)", m, k)
+	}
+	need := (m + 7) / 8
+	if uint32(len(b)-9) < need {
+		return nil, fmt.Errorf("bloom: short input for %d bits", m)
+	}
+	bits := make([]byte, need)
+	copy(bits, b[9:9+need])
+	return &Filter{bits: bitfield.NewBytes(bits, m), k: k}, nil
+}
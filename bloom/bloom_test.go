@@ -0,0 +1,114 @@
+package bloom
+
+import (
+	"encoding/binary"
+	"math/rand"
+	"testing"
+)
+
+func randomBytes(rng *rand.Rand, n int) []byte {
+	b := make([]byte, n)
+	rng.Read(b)
+	return b
+}
+
+func TestNoFalseNegatives(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	f := NewFilter(1000, 0.01)
+	items := make([][]byte, 1000)
+	for i := range items {
+		items[i] = randomBytes(rng, 20)
+		f.Add(items[i])
+	}
+	for i, item := range items {
+		if !f.Contains(item) {
+			t.Fatalf("Contains false for item %d, which was Added -- bloom filters must never false-negative", i)
+		}
+	}
+}
+
+func TestFalsePositiveRateNearTarget(t *testing.T) {
+	const n = 1000
+	const p = 0.01
+	rng := rand.New(rand.NewSource(2))
+	f := NewFilter(n, p)
+	added := make(map[string]bool, n)
+	for i := 0; i < n; i++ {
+		b := randomBytes(rng, 20)
+		added[string(b)] = true
+		f.Add(b)
+	}
+
+	const trials = 20000
+	fp := 0
+	tested := 0
+	for tested < trials {
+		b := randomBytes(rng, 20)
+		if added[string(b)] {
+			continue
+		}
+		tested++
+		if f.Contains(b) {
+			fp++
+		}
+	}
+	rate := float64(fp) / float64(tested)
+	// Generous bounds around the configured 1% target: this is a
+	// probabilistic structure, not an exact one, but a correctly
+	// independent double hash should land close to p, not at ~2x it.
+	if rate > p*2 {
+		t.Fatalf("false-positive rate = %.4f, want close to %.4f (and well under %.4f)", rate, p, p*2)
+	}
+}
+
+func TestNewFilterFromEstimatesClampsZero(t *testing.T) {
+	f := NewFilterFromEstimates(0, 0)
+	f.Add([]byte("x")) // must not panic with a divide by zero
+	if !f.Contains([]byte("x")) {
+		t.Fatal("Contains false right after Add")
+	}
+}
+
+func TestNewFilterFromBytesRejectsZeroM(t *testing.T) {
+	b := make([]byte, 9)
+	b[0] = version
+	binary.BigEndian.PutUint32(b[1:5], 0) // m=0
+	binary.BigEndian.PutUint32(b[5:9], 3) // k=3
+	if _, err := NewFilterFromBytes(b); err == nil {
+		t.Fatal("expected an error for a serialized filter with m=0, which would otherwise divide by zero in Add/Contains")
+	}
+}
+
+func TestBytesRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	f := NewFilter(200, 0.05)
+	item := randomBytes(rng, 10)
+	f.Add(item)
+
+	f2, err := NewFilterFromBytes(f.Bytes())
+	if err != nil {
+		t.Fatalf("NewFilterFromBytes: %v", err)
+	}
+	if !f2.Contains(item) {
+		t.Fatal("Contains false after Bytes/NewFilterFromBytes round trip")
+	}
+}
+
+func TestUnionIntersect(t *testing.T) {
+	a := NewFilterFromEstimates(1000, 4)
+	b := NewFilterFromEstimates(1000, 4)
+	a.Add([]byte("a"))
+	b.Add([]byte("b"))
+
+	if err := a.Union(b); err != nil {
+		t.Fatalf("Union: %v", err)
+	}
+	if !a.Contains([]byte("a")) || !a.Contains([]byte("b")) {
+		t.Fatal("Union does not contain items from both inputs")
+	}
+
+	mismatched := NewFilterFromEstimates(500, 4)
+	if err := a.Union(mismatched); err == nil {
+		t.Fatal("expected an error unioning filters with mismatched m/k")
+	}
+}
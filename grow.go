@@ -0,0 +1,72 @@
+package bitfield
+
+// NewGrowable returns a new Bitfield of initialLength bits whose backing
+// slice has capacity for hintCapacity bits, so that Grow/Append calls up
+// to that size don't reallocate.
+func NewGrowable(initialLength, hintCapacity uint32) *Bitfield {
+	if hintCapacity < initialLength {
+		hintCapacity = initialLength
+	}
+	nBytes, _ := calcSize(initialLength)
+	capBytes, _ := calcSize(hintCapacity)
+	return &Bitfield{make([]byte, nBytes, capBytes), initialLength}
+}
+
+// Grow extends b to newLength bits, preserving existing bits and leaving
+// the new bits clear. Panics if newLength < b.Len(); use Shrink to reduce
+// the length instead.
+func (b *Bitfield) Grow(newLength uint32) {
+	if newLength < b.length {
+		panic("bitfield: Grow to a smaller length, use Shrink instead")
+	}
+	nBytes, _ := calcSize(newLength)
+	oldBytes := uint32(len(b.b))
+	if uint32(cap(b.b)) >= nBytes {
+		b.b = b.b[:nBytes]
+		for i := oldBytes; i < nBytes; i++ {
+			b.b[i] = 0 // bytes exposed from spare capacity may hold stale data
+		}
+	} else {
+		nb := make([]byte, nBytes)
+		copy(nb, b.b)
+		b.b = nb
+	}
+	b.length = newLength
+}
+
+// Shrink truncates b to newLength bits, zeroing any now-invalid trailing
+// bits in the last byte. Panics if newLength > b.Len(); use Grow to
+// increase the length instead.
+func (b *Bitfield) Shrink(newLength uint32) {
+	if newLength > b.length {
+		panic("bitfield: Shrink to a larger length, use Grow instead")
+	}
+	nBytes, nLastBits := calcSize(newLength)
+	b.b = b.b[:nBytes]
+	b.length = newLength
+	if nLastBits != 0 {
+		b.b[len(b.b)-1] &= ^(0xff >> nLastBits)
+	}
+}
+
+// Append appends a single bit to b, growing it by one bit.
+func (b *Bitfield) Append(bit bool) {
+	i := b.length
+	b.Grow(b.length + 1)
+	b.SetTo(i, bit)
+}
+
+// AppendBits appends the low n bits of v, most significant bit first,
+// growing b by n bits. Panics if n > 64.
+func (b *Bitfield) AppendBits(v uint64, n uint8) {
+	if n > 64 {
+		panic("bitfield: AppendBits: n must be <= 64")
+	}
+	start := b.length
+	b.Grow(b.length + uint32(n))
+	for i := uint8(0); i < n; i++ {
+		if (v>>(n-1-i))&1 != 0 {
+			b.Set(start + uint32(i))
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package bitfield
+
+import "testing"
+
+func TestAppendBitsOrdering(t *testing.T) {
+	b := NewGrowable(0, 8)
+	b.AppendBits(0b101, 3)
+	if b.Len() != 3 {
+		t.Fatalf("Len() = %d, want 3", b.Len())
+	}
+	want := []bool{true, false, true}
+	for i, w := range want {
+		if got := b.Test(uint32(i)); got != w {
+			t.Fatalf("bit %d = %v, want %v", i, got, w)
+		}
+	}
+}
+
+func TestAppendBitsAcrossByteBoundary(t *testing.T) {
+	b := NewGrowable(0, 16)
+	b.AppendBits(0b1, 1)  // bit 0
+	b.AppendBits(0xAB, 8) // bits 1..8, most significant bit first
+	b.AppendBits(0b11, 2) // bits 9..10
+	if b.Len() != 11 {
+		t.Fatalf("Len() = %d, want 11", b.Len())
+	}
+	want := []bool{
+		true,                                              // 0b1
+		true, false, true, false, true, false, true, true, // 0xAB = 10101011
+		true, true, // 0b11
+	}
+	for i, w := range want {
+		if got := b.Test(uint32(i)); got != w {
+			t.Fatalf("bit %d = %v, want %v", i, got, w)
+		}
+	}
+}
+
+func TestAppendBitsZeroLength(t *testing.T) {
+	b := NewGrowable(0, 8)
+	b.AppendBits(0xff, 0)
+	if b.Len() != 0 {
+		t.Fatalf("Len() = %d, want 0", b.Len())
+	}
+}
+
+func TestAppendBitsPanicsOnTooManyBits(t *testing.T) {
+	b := NewGrowable(0, 8)
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected panic for n > 64")
+		}
+	}()
+	b.AppendBits(0, 65)
+}
+
+func TestGrowShrinkAppend(t *testing.T) {
+	b := NewGrowable(4, 4)
+	b.SetRange(0, 4)
+	b.Grow(12)
+	for i := uint32(4); i < 12; i++ {
+		if b.Test(i) {
+			t.Fatalf("bit %d set after Grow, want cleared", i)
+		}
+	}
+	b.Shrink(6)
+	if b.Len() != 6 {
+		t.Fatalf("Len() = %d, want 6", b.Len())
+	}
+	b.Append(true)
+	if b.Len() != 7 || !b.Test(6) {
+		t.Fatal("Append did not extend by one set bit")
+	}
+}
@@ -0,0 +1,86 @@
+package bitfield
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// Iter iterates over the bits of a Bitfield in ascending order without
+// allocating. Because this package stores bits most-significant-bit-first,
+// a word read via binary.BigEndian.Uint64 has bit 0 (the lowest index) in
+// its own most significant bit, so NextSet/NextClear use
+// bits.LeadingZeros64 rather than TrailingZeros64 to jump to the next hit.
+type Iter struct {
+	b   *Bitfield
+	pos uint32
+}
+
+// Iter returns a new Iter over b, starting at bit 0.
+func (b *Bitfield) Iter() *Iter {
+	return &Iter{b: b}
+}
+
+// NextSet returns the index of the next set bit at or after the iterator's
+// current position and advances past it. Returns false once exhausted.
+func (it *Iter) NextSet() (uint32, bool) {
+	for it.pos < it.b.length {
+		if it.pos%8 == 0 {
+			byteIdx := it.pos / 8
+			if byteIdx+8 <= uint32(len(it.b.b)) && it.pos+64 <= it.b.length {
+				w := binary.BigEndian.Uint64(it.b.b[byteIdx : byteIdx+8])
+				if w == 0 {
+					it.pos += 64
+					continue
+				}
+				it.pos += uint32(bits.LeadingZeros64(w))
+				found := it.pos
+				it.pos++
+				return found, true
+			}
+			if it.b.b[byteIdx] == 0 {
+				it.pos += 8
+				continue
+			}
+		}
+		if it.b.Test(it.pos) {
+			found := it.pos
+			it.pos++
+			return found, true
+		}
+		it.pos++
+	}
+	return 0, false
+}
+
+// NextClear returns the index of the next clear bit at or after the
+// iterator's current position and advances past it. Returns false once
+// exhausted.
+func (it *Iter) NextClear() (uint32, bool) {
+	for it.pos < it.b.length {
+		if it.pos%8 == 0 {
+			byteIdx := it.pos / 8
+			if byteIdx+8 <= uint32(len(it.b.b)) && it.pos+64 <= it.b.length {
+				w := binary.BigEndian.Uint64(it.b.b[byteIdx : byteIdx+8])
+				if w == ^uint64(0) {
+					it.pos += 64
+					continue
+				}
+				it.pos += uint32(bits.LeadingZeros64(^w))
+				found := it.pos
+				it.pos++
+				return found, true
+			}
+			if it.b.b[byteIdx] == 0xff {
+				it.pos += 8
+				continue
+			}
+		}
+		if !it.b.Test(it.pos) {
+			found := it.pos
+			it.pos++
+			return found, true
+		}
+		it.pos++
+	}
+	return 0, false
+}
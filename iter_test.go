@@ -0,0 +1,78 @@
+package bitfield
+
+import "testing"
+
+func TestIterNextSet(t *testing.T) {
+	const length = 200 // several words plus a partial tail byte
+	set := []uint32{0, 1, 9, 63, 64, 65, 127, 128, 199}
+	b := bitsFromIndices(length, set...)
+
+	it := b.Iter()
+	for _, want := range set {
+		got, ok := it.NextSet()
+		if !ok || got != want {
+			t.Fatalf("NextSet() = (%d,%v), want (%d,true)", got, ok, want)
+		}
+	}
+	if _, ok := it.NextSet(); ok {
+		t.Fatal("NextSet() returned true after exhausting all set bits")
+	}
+}
+
+func TestIterNextClear(t *testing.T) {
+	const length = 200
+	b := New(length)
+	b.SetRange(0, length)
+	clear := []uint32{0, 63, 64, 65, 127, 199}
+	for _, i := range clear {
+		b.Clear(i)
+	}
+
+	it := b.Iter()
+	for _, want := range clear {
+		got, ok := it.NextClear()
+		if !ok || got != want {
+			t.Fatalf("NextClear() = (%d,%v), want (%d,true)", got, ok, want)
+		}
+	}
+	if _, ok := it.NextClear(); ok {
+		t.Fatal("NextClear() returned true after exhausting all clear bits")
+	}
+}
+
+func TestIterNextSetAgreesWithFirstSet(t *testing.T) {
+	// FirstSet is a simple bit-by-bit reference; Iter's word-at-a-time scan
+	// (using LeadingZeros64, since this package's bytes are MSB-first) must
+	// agree with it exactly, including around word and byte boundaries.
+	const length = 137
+	b := bitsFromIndices(length, 2, 3, 8, 64, 65, 66, 100, 136)
+
+	it := b.Iter()
+	next := uint32(0)
+	for {
+		want, wantOK := b.FirstSet(next)
+		got, gotOK := it.NextSet()
+		if gotOK != wantOK || (wantOK && got != want) {
+			t.Fatalf("NextSet() = (%d,%v), want (%d,%v)", got, gotOK, want, wantOK)
+		}
+		if !gotOK {
+			break
+		}
+		next = got + 1
+	}
+}
+
+func TestIterOnEmptyAndFullBitfield(t *testing.T) {
+	empty := New(50)
+	it := empty.Iter()
+	if _, ok := it.NextSet(); ok {
+		t.Fatal("NextSet true on an empty Bitfield")
+	}
+
+	full := New(50)
+	full.SetRange(0, 50)
+	it = full.Iter()
+	if _, ok := it.NextClear(); ok {
+		t.Fatal("NextClear true on a fully-set Bitfield")
+	}
+}
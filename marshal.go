@@ -0,0 +1,158 @@
+package bitfield
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// magic identifies the binary format used by MarshalBinary/WriteTo.
+var magic = [4]byte{'b', 'f', 'l', 'd'}
+
+// binaryVersion is the format version written after magic.
+const binaryVersion = 1
+
+// headerSize is the size of the binary header: magic, version, length.
+const headerSize = 4 + 1 + 4
+
+// readChunkSize bounds how many body bytes ReadFrom allocates at once, so
+// that a header claiming an enormous length can't force a single huge
+// allocation before the stream is known to actually hold that much data.
+const readChunkSize = 64 * 1024
+
+// MarshalBinary implements encoding.BinaryMarshaler. The format is a 4-byte
+// magic, a version byte, the length as a big-endian uint32, then the
+// packed bytes -- so decoding reconstructs the exact length without the
+// caller having to remember it out of band.
+func (b *Bitfield) MarshalBinary() ([]byte, error) {
+	buf := make([]byte, headerSize+len(b.b))
+	copy(buf[:4], magic[:])
+	buf[4] = binaryVersion
+	binary.BigEndian.PutUint32(buf[5:9], b.length)
+	copy(buf[9:], b.b)
+	return buf, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+func (b *Bitfield) UnmarshalBinary(data []byte) error {
+	nb, err := decodeBinary(data)
+	if err != nil {
+		return err
+	}
+	*b = *nb
+	return nil
+}
+
+func decodeBinary(data []byte) (*Bitfield, error) {
+	if len(data) < headerSize {
+		return nil, errors.New("bitfield: short input")
+	}
+	if !bytes.Equal(data[:4], magic[:]) {
+		return nil, errors.New("bitfield: bad magic")
+	}
+	if data[4] != binaryVersion {
+		return nil, fmt.Errorf("bitfield: unsupported version %d", data[4])
+	}
+	length := binary.BigEndian.Uint32(data[5:9])
+	body := make([]byte, len(data)-headerSize)
+	copy(body, data[headerSize:])
+	return NewBytesSafe(body, length)
+}
+
+// WriteTo writes b to w in the same format as MarshalBinary, implementing
+// io.WriterTo.
+func (b *Bitfield) WriteTo(w io.Writer) (int64, error) {
+	data, err := b.MarshalBinary()
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(data)
+	return int64(n), err
+}
+
+// ReadFrom reads a Bitfield previously written by WriteTo, replacing b's
+// contents, implementing io.ReaderFrom. The body is grown in
+// readChunkSize increments as bytes actually arrive, rather than
+// allocated up front from the header's length, so a header claiming an
+// implausible length can't force an oversized allocation before the
+// stream is known to hold that much data.
+func (b *Bitfield) ReadFrom(r io.Reader) (int64, error) {
+	var header [headerSize]byte
+	n, err := io.ReadFull(r, header[:])
+	if err != nil {
+		return int64(n), err
+	}
+	if !bytes.Equal(header[:4], magic[:]) {
+		return int64(n), errors.New("bitfield: bad magic")
+	}
+	if header[4] != binaryVersion {
+		return int64(n), fmt.Errorf("bitfield: unsupported version %d", header[4])
+	}
+	length := binary.BigEndian.Uint32(header[5:9])
+	nBytes, _ := calcSize(length)
+
+	body := make([]byte, 0, minUint32(nBytes, readChunkSize))
+	chunk := make([]byte, readChunkSize)
+	for uint32(len(body)) < nBytes {
+		want := nBytes - uint32(len(body))
+		if want > readChunkSize {
+			want = readChunkSize
+		}
+		rn, rerr := io.ReadFull(r, chunk[:want])
+		n += rn
+		body = append(body, chunk[:rn]...)
+		if rerr != nil {
+			return int64(n), rerr
+		}
+	}
+	nb, err := NewBytesSafe(body, length)
+	if err != nil {
+		return int64(n), err
+	}
+	*b = *nb
+	return int64(n), nil
+}
+
+func minUint32(a, b uint32) uint32 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// jsonBitfield is the wire shape MarshalJSON/UnmarshalJSON use.
+type jsonBitfield struct {
+	Length uint32 `json:"length"`
+	Bits   string `json:"bits"`
+}
+
+// MarshalJSON implements json.Marshaler, encoding as
+// {"length": N, "bits": "base64..."}.
+func (b *Bitfield) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonBitfield{
+		Length: b.length,
+		Bits:   base64.StdEncoding.EncodeToString(b.b),
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (b *Bitfield) UnmarshalJSON(data []byte) error {
+	var j jsonBitfield
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	raw, err := base64.StdEncoding.DecodeString(j.Bits)
+	if err != nil {
+		return fmt.Errorf("bitfield: invalid base64: %w", err)
+	}
+	nb, err := NewBytesSafe(raw, j.Length)
+	if err != nil {
+		return err
+	}
+	*b = *nb
+	return nil
+}
@@ -0,0 +1,106 @@
+package pack
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+)
+
+// Gen writes Go source to w declaring, for each struct value in types, a
+// named unsigned integer type carrying the struct's own name plus
+// Get<Name>/Set<Name> accessor methods for its tagged fields, so that
+// reading and writing fields at runtime costs a shift and a mask instead
+// of a reflection-driven Pack/Unpack round trip. The output is
+// self-contained: it declares `type <Name> uintN` itself, so the struct
+// passed in should live in a different package than pkg (typically a
+// schema package that only exists to carry the tags) to avoid a duplicate
+// type declaration.
+//
+// Returns an error if any struct's tags are invalid, or if the sum of its
+// fields' bit widths exceeds 64, the largest integer width this package
+// can target.
+func Gen(w io.Writer, pkg string, types ...any) error {
+	fmt.Fprintf(w, "// Code generated by bitfield/pack. DO NOT EDIT.\n\n")
+	fmt.Fprintf(w, "package %s\n\n", pkg)
+	for _, v := range types {
+		if err := genType(w, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func genType(w io.Writer, v any) error {
+	t := reflect.TypeOf(v)
+	fields, err := parseFields(t)
+	if err != nil {
+		return err
+	}
+	total := totalBits(fields)
+	width, err := targetWidth(total)
+	if err != nil {
+		return fmt.Errorf("bitfield/pack: %s: %w", t.Name(), err)
+	}
+	fmt.Fprintf(w, "// %s is a bit-packed form of the tagged struct of the same name,\n// most significant field first.\n", t.Name())
+	fmt.Fprintf(w, "type %s uint%d\n\n", t.Name(), width)
+	var offset uint32
+	for _, f := range fields {
+		shift := total - offset - uint32(f.Bits)
+		genAccessors(w, t.Name(), f, shift)
+		offset += uint32(f.Bits)
+	}
+	return nil
+}
+
+func genAccessors(w io.Writer, typeName string, f taggedField, shift uint32) {
+	goType := fieldGoType(f)
+	fmt.Fprintf(w, "// Get%s returns the %s field of %s.\n", f.Name, f.Name, typeName)
+	fmt.Fprintf(w, "func (v %s) Get%s() %s {\n", typeName, f.Name, goType)
+	if f.Kind == reflect.Bool {
+		fmt.Fprintf(w, "\treturn (v>>%d)&1 != 0\n}\n\n", shift)
+	} else {
+		mask := uint64(1)<<f.Bits - 1
+		fmt.Fprintf(w, "\treturn %s((v >> %d) & 0x%x)\n}\n\n", goType, shift, mask)
+	}
+
+	fmt.Fprintf(w, "// Set%s sets the %s field of %s.\n", f.Name, f.Name, typeName)
+	fmt.Fprintf(w, "func (v *%s) Set%s(x %s) {\n", typeName, f.Name, goType)
+	if f.Kind == reflect.Bool {
+		fmt.Fprintf(w, "\tif x {\n\t\t*v |= 1 << %d\n\t} else {\n\t\t*v &^= 1 << %d\n\t}\n}\n\n", shift, shift)
+	} else {
+		mask := uint64(1)<<f.Bits - 1
+		fmt.Fprintf(w, "\t*v = (*v &^ (%s(0x%x) << %d)) | %s(x)&0x%x<<%d\n}\n\n", typeName, mask, shift, typeName, mask, shift)
+	}
+}
+
+// targetWidth returns the narrowest of 8/16/32/64 bits that fits total.
+func targetWidth(total uint32) (int, error) {
+	switch {
+	case total <= 8:
+		return 8, nil
+	case total <= 16:
+		return 16, nil
+	case total <= 32:
+		return 32, nil
+	case total <= 64:
+		return 64, nil
+	default:
+		return 0, fmt.Errorf("total bits %d exceed the target integer width", total)
+	}
+}
+
+func fieldGoType(f taggedField) string {
+	if f.Kind == reflect.Bool {
+		return "bool"
+	}
+	switch {
+	case f.Bits <= 8:
+		return "uint8"
+	case f.Bits <= 16:
+		return "uint16"
+	case f.Bits <= 32:
+		return "uint32"
+	default:
+		return "uint64"
+	}
+}
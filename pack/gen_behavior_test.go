@@ -0,0 +1,82 @@
+package pack
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestGenAccessorsBehavior checks that the accessors Gen emits actually
+// shift/mask correctly -- gen_test.go only proves the output compiles, not
+// that Get/Set round-trip values or mask out-of-range bits.
+func TestGenAccessorsBehavior(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go toolchain not available")
+	}
+
+	var buf strings.Builder
+	if err := Gen(&buf, "main", genExample{}); err != nil {
+		t.Fatalf("Gen: %v", err)
+	}
+
+	const main = `package main
+
+import "fmt"
+
+func main() {
+	var v genExample
+	v.SetA(5)
+	v.SetB(4000)
+	v.SetFlag(true)
+	if v.GetA() != 5 || v.GetB() != 4000 || !v.GetFlag() {
+		fmt.Printf("FAIL: got A=%d B=%d Flag=%v\n", v.GetA(), v.GetB(), v.GetFlag())
+		return
+	}
+
+	// SetA's tag only claims 3 bits; a wider input must be masked down
+	// rather than bleeding into B's bits.
+	var w genExample
+	w.SetA(0xff)
+	w.SetB(0xfff)
+	if w.GetA() != 0x7 || w.GetB() != 0xfff {
+		fmt.Printf("FAIL: overflow not masked, got A=%d B=%d\n", w.GetA(), w.GetB())
+		return
+	}
+
+	w.SetFlag(false)
+	if w.GetFlag() {
+		fmt.Println("FAIL: Flag still true after SetFlag(false)")
+		return
+	}
+	if w.GetA() != 0x7 || w.GetB() != 0xfff {
+		fmt.Println("FAIL: SetFlag disturbed other fields")
+		return
+	}
+
+	fmt.Println("OK")
+}
+`
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module genexamplemain\n\ngo 1.21\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "genexample.go"), []byte(buf.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte(main), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command("go", "run", ".")
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("go run: %v\n%s", err, out)
+	}
+	if got := strings.TrimSpace(string(out)); got != "OK" {
+		t.Fatalf("generated accessors misbehaved:\n%s", out)
+	}
+}
@@ -0,0 +1,58 @@
+package pack
+
+import (
+	"bytes"
+	"go/parser"
+	"go/token"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+type genExample struct {
+	A    uint8  `bitfield:",3"`
+	B    uint16 `bitfield:",12"`
+	Flag bool   `bitfield:",1"`
+}
+
+// TestGenParses checks that Gen's output is syntactically valid Go.
+func TestGenParses(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Gen(&buf, "genexample", genExample{}); err != nil {
+		t.Fatalf("Gen: %v", err)
+	}
+	fset := token.NewFileSet()
+	if _, err := parser.ParseFile(fset, "genexample.go", buf.Bytes(), 0); err != nil {
+		t.Fatalf("generated code does not parse: %v\n%s", err, buf.String())
+	}
+}
+
+// TestGenCompiles checks that Gen's output is a self-contained, compilable
+// package on its own -- in particular, that it declares the backing
+// integer type itself rather than assuming the caller already has one.
+func TestGenCompiles(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go toolchain not available")
+	}
+
+	var buf bytes.Buffer
+	if err := Gen(&buf, "genexample", genExample{}); err != nil {
+		t.Fatalf("Gen: %v", err)
+	}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module genexample\n\ngo 1.21\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "genexample.go"), buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command("go", "build", "./...")
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("generated package failed to compile: %v\n%s", err, out)
+	}
+}
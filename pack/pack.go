@@ -0,0 +1,102 @@
+package pack
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/cenkalti/bitfield"
+)
+
+// Pack packs the tagged fields of the struct v (a struct or a pointer to
+// one) into a new Bitfield, in declaration order, most significant bit
+// first. Fields without a `bitfield` tag are ignored.
+func Pack(v any) (*bitfield.Bitfield, error) {
+	rv := indirect(reflect.ValueOf(v))
+	fields, err := parseFields(rv.Type())
+	if err != nil {
+		return nil, err
+	}
+	bf := bitfield.New(totalBits(fields))
+	var offset uint32
+	for _, f := range fields {
+		writeBits(bf, offset, f.Bits, fieldValue(rv.Field(f.Index), f.Kind))
+		offset += uint32(f.Bits)
+	}
+	return bf, nil
+}
+
+// Unpack unpacks b into the tagged fields of the struct pointed to by v,
+// using the same field order and layout Pack produces.
+func Unpack(b *bitfield.Bitfield, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("bitfield/pack: Unpack requires a pointer to a struct")
+	}
+	rv = rv.Elem()
+	fields, err := parseFields(rv.Type())
+	if err != nil {
+		return err
+	}
+	if need := totalBits(fields); need > b.Len() {
+		return fmt.Errorf("bitfield/pack: bitfield has %d bits, need %d", b.Len(), need)
+	}
+	var offset uint32
+	for _, f := range fields {
+		val := readBits(b, offset, f.Bits)
+		offset += uint32(f.Bits)
+		fv := rv.Field(f.Index)
+		if f.Kind == reflect.Bool {
+			fv.SetBool(val != 0)
+		} else {
+			fv.SetUint(val)
+		}
+	}
+	return nil
+}
+
+func indirect(rv reflect.Value) reflect.Value {
+	for rv.Kind() == reflect.Ptr {
+		rv = rv.Elem()
+	}
+	return rv
+}
+
+func totalBits(fields []taggedField) uint32 {
+	var total uint32
+	for _, f := range fields {
+		total += uint32(f.Bits)
+	}
+	return total
+}
+
+func fieldValue(fv reflect.Value, kind reflect.Kind) uint64 {
+	if kind == reflect.Bool {
+		if fv.Bool() {
+			return 1
+		}
+		return 0
+	}
+	return fv.Uint()
+}
+
+// writeBits writes the low bits bits of val into the bitfield starting at
+// offset, the field's own most significant bit first. Higher bits of val
+// are ignored.
+func writeBits(bf *bitfield.Bitfield, offset uint32, bits uint8, val uint64) {
+	for i := uint8(0); i < bits; i++ {
+		if (val>>(bits-1-i))&1 != 0 {
+			bf.Set(offset + uint32(i))
+		}
+	}
+}
+
+func readBits(b *bitfield.Bitfield, offset uint32, bits uint8) uint64 {
+	var val uint64
+	for i := uint8(0); i < bits; i++ {
+		val <<= 1
+		if b.Test(offset + uint32(i)) {
+			val |= 1
+		}
+	}
+	return val
+}
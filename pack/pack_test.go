@@ -0,0 +1,59 @@
+package pack
+
+import "testing"
+
+type packExample struct {
+	A    uint8  `bitfield:",3"`
+	B    uint16 `bitfield:",12"`
+	Flag bool   `bitfield:",1"`
+}
+
+func TestPackUnpackRoundTrip(t *testing.T) {
+	in := packExample{A: 5, B: 4000, Flag: true}
+	bf, err := Pack(&in)
+	if err != nil {
+		t.Fatalf("Pack: %v", err)
+	}
+	if want := uint32(3 + 12 + 1); bf.Len() != want {
+		t.Fatalf("Len() = %d, want %d", bf.Len(), want)
+	}
+
+	var out packExample
+	if err := Unpack(bf, &out); err != nil {
+		t.Fatalf("Unpack: %v", err)
+	}
+	if out != in {
+		t.Fatalf("Unpack(Pack(%+v)) = %+v", in, out)
+	}
+}
+
+func TestPackMasksOverflowingValues(t *testing.T) {
+	// A's tag only claims 3 bits, so Pack must only take A's low 3 bits
+	// into account -- the generated/reflective accessors are only as
+	// correct as this masking.
+	in := packExample{A: 0xff, B: 0xfff, Flag: true}
+	bf, err := Pack(&in)
+	if err != nil {
+		t.Fatalf("Pack: %v", err)
+	}
+	var out packExample
+	if err := Unpack(bf, &out); err != nil {
+		t.Fatalf("Unpack: %v", err)
+	}
+	if out.A != 7 || out.B != 0xfff || !out.Flag {
+		t.Fatalf("Unpack = %+v, want A=7,B=4095,Flag=true", out)
+	}
+}
+
+func TestUnpackErrorsOnShortBitfield(t *testing.T) {
+	short, err := Pack(&struct {
+		A uint8 `bitfield:",3"`
+	}{})
+	if err != nil {
+		t.Fatalf("Pack: %v", err)
+	}
+	var out packExample
+	if err := Unpack(short, &out); err == nil {
+		t.Fatal("expected error unpacking a too-short Bitfield")
+	}
+}
@@ -0,0 +1,83 @@
+// Package pack packs and unpacks the exported fields of a Go struct into a
+// *bitfield.Bitfield using `bitfield:"name,bits"` struct tags, following the
+// same most-significant-bit-first layout the bitfield package itself uses.
+package pack
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// taggedField describes one struct field packed into a Bitfield.
+type taggedField struct {
+	Name  string // name to use for generated accessors; defaults to the Go field name
+	Bits  uint8  // width in bits
+	Index int    // index into reflect.Value.Field
+	Kind  reflect.Kind
+}
+
+// parseFields validates t's bitfield tags and returns one taggedField per
+// tagged field, in declaration order. Fields without a `bitfield` tag are
+// skipped.
+func parseFields(t reflect.Type) ([]taggedField, error) {
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("bitfield/pack: %s is not a struct", t)
+	}
+	var fields []taggedField
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag, ok := sf.Tag.Lookup("bitfield")
+		if !ok {
+			continue
+		}
+		name, bits, err := parseTag(tag, sf.Name)
+		if err != nil {
+			return nil, fmt.Errorf("bitfield/pack: field %s: %w", sf.Name, err)
+		}
+		if err := checkFieldType(sf.Type, bits); err != nil {
+			return nil, fmt.Errorf("bitfield/pack: field %s: %w", sf.Name, err)
+		}
+		fields = append(fields, taggedField{Name: name, Bits: bits, Index: i, Kind: sf.Type.Kind()})
+	}
+	return fields, nil
+}
+
+// parseTag parses a `name,bits` tag. An empty name defaults to fieldName.
+func parseTag(tag, fieldName string) (name string, bits uint8, err error) {
+	parts := strings.SplitN(tag, ",", 2)
+	name = parts[0]
+	if name == "" {
+		name = fieldName
+	}
+	if len(parts) != 2 {
+		return "", 0, fmt.Errorf("missing bit width in tag %q", tag)
+	}
+	n, err := strconv.Atoi(parts[1])
+	if err != nil || n <= 0 || n > 64 {
+		return "", 0, fmt.Errorf("invalid bit width in tag %q", tag)
+	}
+	return name, uint8(n), nil
+}
+
+// checkFieldType verifies that bits is a legal width for t: 1 for bool,
+// capped by the type's own size for unsigned integers, and rejected for
+// signed integers.
+func checkFieldType(t reflect.Type, bits uint8) error {
+	switch t.Kind() {
+	case reflect.Bool:
+		if bits != 1 {
+			return fmt.Errorf("bool field must have width 1, got %d", bits)
+		}
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uint:
+		if int(bits) > t.Bits() {
+			return fmt.Errorf("width %d does not fit in %s", bits, t)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return fmt.Errorf("signed integer type %s is not allowed", t)
+	default:
+		return fmt.Errorf("unsupported field type %s", t)
+	}
+	return nil
+}
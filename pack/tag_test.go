@@ -0,0 +1,24 @@
+package pack
+
+import "testing"
+
+func TestParseTagRejectsOutOfRangeWidth(t *testing.T) {
+	if _, _, err := parseTag(",256", "Field"); err == nil {
+		t.Fatal("expected error for width 256, which overflows uint8 and must not silently wrap to 0")
+	}
+	if _, _, err := parseTag(",64", "Field"); err != nil {
+		t.Fatalf("parseTag(,64): unexpected error %v", err)
+	}
+	if _, _, err := parseTag(",65", "Field"); err == nil {
+		t.Fatal("expected error for width 65, above the 64-bit integer ceiling")
+	}
+}
+
+func TestPackRejectsOversizedWidthTag(t *testing.T) {
+	type bad struct {
+		A uint8 `bitfield:",256"`
+	}
+	if _, err := Pack(&bad{}); err == nil {
+		t.Fatal("expected Pack to reject a 256-bit-wide tag instead of silently wrapping it to 0 bits")
+	}
+}
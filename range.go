@@ -0,0 +1,121 @@
+package bitfield
+
+import "encoding/binary"
+
+// checkRange panics if start > end or end > b.Len().
+func (b *Bitfield) checkRange(start, end uint32) {
+	if start > end || end > b.length {
+		panic("index out of bound")
+	}
+}
+
+// fillWords sets p to repeated copies of val (0x00 or 0xff), writing 8
+// bytes at a time via binary.BigEndian where possible.
+func fillWords(p []byte, val byte) {
+	i := 0
+	if val == 0x00 || val == 0xff {
+		var w uint64
+		if val == 0xff {
+			w = ^uint64(0)
+		}
+		for ; i+8 <= len(p); i += 8 {
+			binary.BigEndian.PutUint64(p[i:i+8], w)
+		}
+	}
+	for ; i < len(p); i++ {
+		p[i] = val
+	}
+}
+
+// flipWords flips every bit in p, 8 bytes at a time where possible.
+func flipWords(p []byte) {
+	i := 0
+	for ; i+8 <= len(p); i += 8 {
+		w := binary.BigEndian.Uint64(p[i : i+8])
+		binary.BigEndian.PutUint64(p[i:i+8], ^w)
+	}
+	for ; i < len(p); i++ {
+		p[i] = ^p[i]
+	}
+}
+
+// SetRange sets the bits in [start, end). Panics if start > end or
+// end > b.Len().
+func (b *Bitfield) SetRange(start, end uint32) {
+	b.checkRange(start, end)
+	if start == end {
+		return
+	}
+	startByte, startBit := divMod32(start, 8)
+	endByte, endBit := divMod32(end, 8)
+	if startByte == endByte {
+		b.b[startByte] |= (byte(0xff) >> startBit) &^ (byte(0xff) >> endBit)
+		return
+	}
+	if startBit != 0 {
+		b.b[startByte] |= byte(0xff) >> startBit
+		startByte++
+	}
+	fillWords(b.b[startByte:endByte], 0xff)
+	if endBit != 0 {
+		b.b[endByte] |= ^(byte(0xff) >> endBit)
+	}
+}
+
+// ClearRange clears the bits in [start, end). Panics if start > end or
+// end > b.Len().
+func (b *Bitfield) ClearRange(start, end uint32) {
+	b.checkRange(start, end)
+	if start == end {
+		return
+	}
+	startByte, startBit := divMod32(start, 8)
+	endByte, endBit := divMod32(end, 8)
+	if startByte == endByte {
+		b.b[startByte] &^= (byte(0xff) >> startBit) &^ (byte(0xff) >> endBit)
+		return
+	}
+	if startBit != 0 {
+		b.b[startByte] &^= byte(0xff) >> startBit
+		startByte++
+	}
+	fillWords(b.b[startByte:endByte], 0x00)
+	if endBit != 0 {
+		b.b[endByte] &^= ^(byte(0xff) >> endBit)
+	}
+}
+
+// Flip flips bit i. Panics if i >= b.Len().
+func (b *Bitfield) Flip(i uint32) {
+	b.checkIndex(i)
+	div, mod := divMod32(i, 8)
+	b.b[div] ^= 1 << (7 - mod)
+}
+
+// FlipRange flips the bits in [start, end). Panics if start > end or
+// end > b.Len().
+func (b *Bitfield) FlipRange(start, end uint32) {
+	b.checkRange(start, end)
+	if start == end {
+		return
+	}
+	startByte, startBit := divMod32(start, 8)
+	endByte, endBit := divMod32(end, 8)
+	if startByte == endByte {
+		b.b[startByte] ^= (byte(0xff) >> startBit) &^ (byte(0xff) >> endBit)
+		return
+	}
+	if startBit != 0 {
+		b.b[startByte] ^= byte(0xff) >> startBit
+		startByte++
+	}
+	flipWords(b.b[startByte:endByte])
+	if endBit != 0 {
+		b.b[endByte] ^= ^(byte(0xff) >> endBit)
+	}
+}
+
+// FlipAll flips every bit.
+func (b *Bitfield) FlipAll() {
+	b.FlipRange(0, b.length)
+}
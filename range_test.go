@@ -0,0 +1,99 @@
+package bitfield
+
+import "testing"
+
+// referenceApply builds a Bitfield of length bits by calling op on every
+// index in [0,length) bit by bit, as an oracle for the word-at-a-time
+// SetRange/ClearRange/FlipRange implementations.
+func referenceApply(length uint32, op func(b *Bitfield, i uint32)) *Bitfield {
+	b := New(length)
+	for i := uint32(0); i < length; i++ {
+		op(b, i)
+	}
+	return b
+}
+
+func TestSetRangeClearRangeAgainstBitByBit(t *testing.T) {
+	const length = 80 // several full bytes plus a partial tail byte
+	for start := uint32(0); start <= length; start += 3 {
+		for end := start; end <= length; end += 5 {
+			b := New(length)
+			b.SetRange(start, end)
+			want := New(length)
+			for i := start; i < end; i++ {
+				want.Set(i)
+			}
+			if !b.Equals(want) {
+				t.Fatalf("SetRange(%d,%d) = %x, want %x", start, end, b.Bytes(), want.Bytes())
+			}
+
+			full := New(length)
+			full.SetRange(0, length)
+			full.ClearRange(start, end)
+			wantClearFull := New(length)
+			wantClearFull.SetRange(0, length)
+			for i := start; i < end; i++ {
+				wantClearFull.Clear(i)
+			}
+			if !full.Equals(wantClearFull) {
+				t.Fatalf("ClearRange(%d,%d) = %x, want %x", start, end, full.Bytes(), wantClearFull.Bytes())
+			}
+		}
+	}
+}
+
+func TestSetRangeDoesNotTouchBitsOutsideRange(t *testing.T) {
+	const length = 40
+	b := New(length)
+	b.SetRange(10, 20)
+	for i := uint32(0); i < length; i++ {
+		want := i >= 10 && i < 20
+		if b.Test(i) != want {
+			t.Fatalf("bit %d = %v, want %v", i, b.Test(i), want)
+		}
+	}
+}
+
+func TestFlipRangeAgainstBitByBit(t *testing.T) {
+	const length = 72
+	for start := uint32(0); start <= length; start += 7 {
+		for end := start; end <= length; end += 11 {
+			b := bitsFromIndices(length, 1, 9, 17, 33, 64, 71)
+			want := b.clone()
+			b.FlipRange(start, end)
+			for i := start; i < end; i++ {
+				want.Flip(i)
+			}
+			if !b.Equals(want) {
+				t.Fatalf("FlipRange(%d,%d) = %x, want %x", start, end, b.Bytes(), want.Bytes())
+			}
+		}
+	}
+}
+
+func TestFlipAll(t *testing.T) {
+	const length = 13 // not a multiple of 8, to exercise the tail byte
+	b := bitsFromIndices(length, 0, 3, 12)
+	want := New(length)
+	for i := uint32(0); i < length; i++ {
+		want.SetTo(i, !b.Test(i))
+	}
+	b.FlipAll()
+	if !b.Equals(want) {
+		t.Fatalf("FlipAll = %x, want %x", b.Bytes(), want.Bytes())
+	}
+}
+
+func TestRangePanicsOnBadBounds(t *testing.T) {
+	b := New(10)
+	for _, fn := range []func(uint32, uint32){b.SetRange, b.ClearRange, b.FlipRange} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatal("expected panic for end > length")
+				}
+			}()
+			fn(0, 11)
+		}()
+	}
+}
@@ -0,0 +1,177 @@
+package bitfield
+
+import "encoding/binary"
+
+// checkSameLength panics if b and other do not have the same length.
+// All of the set operations below require equal-length operands; callers
+// that need to combine differently sized Bitfields must Grow/Shrink one of
+// them first.
+func (b *Bitfield) checkSameLength(other *Bitfield) {
+	if b.length != other.length {
+		panic("bitfield: length mismatch")
+	}
+}
+
+// clone returns a copy of b that does not share its backing array.
+func (b *Bitfield) clone() *Bitfield {
+	nb := make([]byte, len(b.b))
+	copy(nb, b.b)
+	return &Bitfield{nb, b.length}
+}
+
+// applyWordwise combines dst and src into dst, processing 8-byte chunks at a
+// time via op64 and any trailing bytes via op8. dst and src must have equal
+// length.
+func applyWordwise(dst, src []byte, op64 func(a, c uint64) uint64, op8 func(a, c byte) byte) {
+	n := len(dst)
+	i := 0
+	for ; i+8 <= n; i += 8 {
+		a := binary.BigEndian.Uint64(dst[i : i+8])
+		c := binary.BigEndian.Uint64(src[i : i+8])
+		binary.BigEndian.PutUint64(dst[i:i+8], op64(a, c))
+	}
+	for ; i < n; i++ {
+		dst[i] = op8(dst[i], src[i])
+	}
+}
+
+// InPlaceUnion sets b to the bitwise union of b and other.
+// Panics if b and other do not have the same length.
+func (b *Bitfield) InPlaceUnion(other *Bitfield) {
+	b.checkSameLength(other)
+	applyWordwise(b.b, other.b,
+		func(a, c uint64) uint64 { return a | c },
+		func(a, c byte) byte { return a | c })
+}
+
+// Union returns a new Bitfield that is the bitwise union of b and other.
+// Panics if b and other do not have the same length.
+func (b *Bitfield) Union(other *Bitfield) *Bitfield {
+	result := b.clone()
+	result.InPlaceUnion(other)
+	return result
+}
+
+// InPlaceIntersection sets b to the bitwise intersection of b and other.
+// Panics if b and other do not have the same length.
+func (b *Bitfield) InPlaceIntersection(other *Bitfield) {
+	b.checkSameLength(other)
+	applyWordwise(b.b, other.b,
+		func(a, c uint64) uint64 { return a & c },
+		func(a, c byte) byte { return a & c })
+}
+
+// Intersection returns a new Bitfield that is the bitwise intersection of b
+// and other. Panics if b and other do not have the same length.
+func (b *Bitfield) Intersection(other *Bitfield) *Bitfield {
+	result := b.clone()
+	result.InPlaceIntersection(other)
+	return result
+}
+
+// InPlaceDifference sets b to the bits in b that are not in other (b &^ other).
+// Panics if b and other do not have the same length.
+func (b *Bitfield) InPlaceDifference(other *Bitfield) {
+	b.checkSameLength(other)
+	applyWordwise(b.b, other.b,
+		func(a, c uint64) uint64 { return a &^ c },
+		func(a, c byte) byte { return a &^ c })
+}
+
+// Difference returns a new Bitfield with the bits in b that are not in other.
+// Panics if b and other do not have the same length.
+func (b *Bitfield) Difference(other *Bitfield) *Bitfield {
+	result := b.clone()
+	result.InPlaceDifference(other)
+	return result
+}
+
+// InPlaceSymmetricDifference sets b to the bits that are set in exactly one
+// of b and other (b ^ other). Panics if b and other do not have the same
+// length.
+func (b *Bitfield) InPlaceSymmetricDifference(other *Bitfield) {
+	b.checkSameLength(other)
+	applyWordwise(b.b, other.b,
+		func(a, c uint64) uint64 { return a ^ c },
+		func(a, c byte) byte { return a ^ c })
+}
+
+// SymmetricDifference returns a new Bitfield with the bits that are set in
+// exactly one of b and other. Panics if b and other do not have the same
+// length.
+func (b *Bitfield) SymmetricDifference(other *Bitfield) *Bitfield {
+	result := b.clone()
+	result.InPlaceSymmetricDifference(other)
+	return result
+}
+
+// InPlaceComplement flips every bit in b. Unused bits in the last byte are
+// left cleared.
+func (b *Bitfield) InPlaceComplement() {
+	for i := range b.b {
+		b.b[i] = ^b.b[i]
+	}
+	b.clearTailBits()
+}
+
+// Complement returns a new Bitfield with every bit flipped.
+func (b *Bitfield) Complement() *Bitfield {
+	result := b.clone()
+	result.InPlaceComplement()
+	return result
+}
+
+// clearTailBits clears the bits in the last byte that are past b.length,
+// mirroring the masking done in NewBytes.
+func (b *Bitfield) clearTailBits() {
+	_, nLastBits := calcSize(b.length)
+	if nLastBits != 0 {
+		b.b[len(b.b)-1] &= ^(0xff >> nLastBits)
+	}
+}
+
+// Equals reports whether b and other have the same length and the same bits set.
+func (b *Bitfield) Equals(other *Bitfield) bool {
+	if b.length != other.length {
+		return false
+	}
+	for i := range b.b {
+		if b.b[i] != other.b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// IsSubset reports whether every bit set in b is also set in other.
+// Panics if b and other do not have the same length.
+func (b *Bitfield) IsSubset(other *Bitfield) bool {
+	b.checkSameLength(other)
+	for i := range b.b {
+		if b.b[i]&^other.b[i] != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// IsSuperset reports whether every bit set in other is also set in b.
+// Panics if b and other do not have the same length.
+func (b *Bitfield) IsSuperset(other *Bitfield) bool {
+	return other.IsSubset(b)
+}
+
+// Any returns true if at least one bit is set.
+func (b *Bitfield) Any() bool {
+	for _, v := range b.b {
+		if v != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// None returns true if no bit is set.
+func (b *Bitfield) None() bool {
+	return !b.Any()
+}
@@ -0,0 +1,116 @@
+package bitfield
+
+import "testing"
+
+func bitsFromIndices(length uint32, idx ...uint32) *Bitfield {
+	b := New(length)
+	for _, i := range idx {
+		b.Set(i)
+	}
+	return b
+}
+
+func TestSetOps(t *testing.T) {
+	const length = 37 // spans a partial tail byte
+	a := bitsFromIndices(length, 0, 1, 2, 5, 10, 36)
+	c := bitsFromIndices(length, 1, 2, 3, 6, 10, 36)
+
+	union := a.Union(c)
+	wantUnion := bitsFromIndices(length, 0, 1, 2, 3, 5, 6, 10, 36)
+	if !union.Equals(wantUnion) {
+		t.Fatalf("Union = %x, want %x", union.Bytes(), wantUnion.Bytes())
+	}
+
+	inter := a.Intersection(c)
+	wantInter := bitsFromIndices(length, 1, 2, 10, 36)
+	if !inter.Equals(wantInter) {
+		t.Fatalf("Intersection = %x, want %x", inter.Bytes(), wantInter.Bytes())
+	}
+
+	diff := a.Difference(c)
+	wantDiff := bitsFromIndices(length, 0, 5)
+	if !diff.Equals(wantDiff) {
+		t.Fatalf("Difference = %x, want %x", diff.Bytes(), wantDiff.Bytes())
+	}
+
+	sym := a.SymmetricDifference(c)
+	wantSym := bitsFromIndices(length, 0, 3, 5, 6)
+	if !sym.Equals(wantSym) {
+		t.Fatalf("SymmetricDifference = %x, want %x", sym.Bytes(), wantSym.Bytes())
+	}
+
+	comp := a.Complement()
+	for i := uint32(0); i < length; i++ {
+		if comp.Test(i) == a.Test(i) {
+			t.Fatalf("Complement bit %d not flipped", i)
+		}
+	}
+	// Complement must clear the unused tail bits rather than leave them set.
+	if comp.b[len(comp.b)-1]&0x07 != 0 {
+		t.Fatalf("Complement left unused tail bits set: %08b", comp.b[len(comp.b)-1])
+	}
+
+	// Operands must be untouched by the non-in-place variants.
+	if !a.Equals(bitsFromIndices(length, 0, 1, 2, 5, 10, 36)) {
+		t.Fatal("Union/Intersection/etc. mutated operand a")
+	}
+}
+
+func TestInPlaceSetOps(t *testing.T) {
+	const length = 16
+	a := bitsFromIndices(length, 0, 1, 2)
+	c := bitsFromIndices(length, 1, 2, 3)
+	a.InPlaceUnion(c)
+	if !a.Equals(bitsFromIndices(length, 0, 1, 2, 3)) {
+		t.Fatalf("InPlaceUnion = %x", a.Bytes())
+	}
+}
+
+func TestSetOpsPanicOnLengthMismatch(t *testing.T) {
+	a := New(8)
+	c := New(16)
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected panic for length mismatch")
+		}
+	}()
+	a.InPlaceUnion(c)
+}
+
+func TestIsSubsetIsSuperset(t *testing.T) {
+	const length = 20
+	sub := bitsFromIndices(length, 1, 2)
+	super := bitsFromIndices(length, 1, 2, 3)
+	if !sub.IsSubset(super) {
+		t.Fatal("IsSubset false for an actual subset")
+	}
+	if !super.IsSuperset(sub) {
+		t.Fatal("IsSuperset false for an actual superset")
+	}
+	if super.IsSubset(sub) {
+		t.Fatal("IsSubset true when it shouldn't be")
+	}
+}
+
+func TestEqualsLengthMismatch(t *testing.T) {
+	if New(8).Equals(New(16)) {
+		t.Fatal("Equals true for Bitfields of different length")
+	}
+}
+
+func TestAnyNone(t *testing.T) {
+	b := New(10)
+	if b.Any() {
+		t.Fatal("Any true on empty Bitfield")
+	}
+	if !b.None() {
+		t.Fatal("None false on empty Bitfield")
+	}
+	b.Set(4)
+	if !b.Any() {
+		t.Fatal("Any false after Set")
+	}
+	if b.None() {
+		t.Fatal("None true after Set")
+	}
+}
@@ -0,0 +1,364 @@
+// Package sparse implements a Roaring-bitmap-style compressed bitmap,
+// SparseBitfield, for index sets that are very large but mostly empty or
+// mostly full. The 32-bit index space is split into a 16-bit high half (the
+// container key) and a 16-bit low half; each container holds the low bits
+// belonging to one key using whichever of three representations is
+// cheapest for its current contents.
+package sparse
+
+import (
+	"math/bits"
+	"sort"
+)
+
+const (
+	// arrayMaxCardinality is the cardinality above which an array
+	// container is never cheaper than a bitmap container (2 bytes per
+	// value vs. a fixed 8 KiB).
+	arrayMaxCardinality = 4096
+	// bitmapWords is the number of uint64 words in a bitmap container:
+	// 1024 * 64 = 65536, one bit per possible low-16 value.
+	bitmapWords = 1024
+)
+
+// run is an inclusive-length run of consecutive values: it covers
+// [start, start+length-1]. length is a uint32, not a uint16, because a
+// single run can legitimately cover the whole 16-bit value space (length
+// 65536), which doesn't fit in a uint16.
+type run struct {
+	start  uint16
+	length uint32
+}
+
+// container holds the low 16 bits of the indices sharing one high-16 key.
+// The three implementations -- arrayContainer, bitmapContainer and
+// runContainer -- all keep values in ascending order so forEach/first can
+// be relied on to visit values in order.
+type container interface {
+	set(v uint16) container
+	clear(v uint16) container
+	test(v uint16) bool
+	count() int
+	clone() container
+	forEach(fn func(uint16))
+	first(from uint16) (uint16, bool)
+	firstClear(from uint16) (uint16, bool)
+}
+
+// optimize rebuilds c in whichever of the three representations is
+// smallest for its current contents, and is called after every mutation.
+func optimize(c container) container {
+	n := c.count()
+	if n == 0 {
+		return arrayContainer(nil)
+	}
+	values := make([]uint16, 0, n)
+	c.forEach(func(v uint16) { values = append(values, v) })
+
+	arraySize := 2 * n
+	bitmapSize := bitmapWords * 8
+	runSize := 4 * countRuns(values)
+
+	best, kind := arraySize, 0
+	if bitmapSize < best {
+		best, kind = bitmapSize, 1
+	}
+	if runSize < best {
+		kind = 2
+	}
+
+	switch kind {
+	case 1:
+		return bitmapFromValues(values)
+	case 2:
+		return runFromValues(values)
+	default:
+		return arrayContainer(values)
+	}
+}
+
+func countRuns(values []uint16) int {
+	if len(values) == 0 {
+		return 0
+	}
+	runs := 1
+	for i := 1; i < len(values); i++ {
+		if uint32(values[i]) != uint32(values[i-1])+1 {
+			runs++
+		}
+	}
+	return runs
+}
+
+func runFromValues(values []uint16) runContainer {
+	c := make(runContainer, 0, countRuns(values))
+	start, length := values[0], uint32(1)
+	for i := 1; i < len(values); i++ {
+		if uint32(values[i]) == uint32(values[i-1])+1 {
+			length++
+			continue
+		}
+		c = append(c, run{start, length})
+		start, length = values[i], 1
+	}
+	return append(c, run{start, length})
+}
+
+func bitmapFromValues(values []uint16) *bitmapContainer {
+	var c bitmapContainer
+	for _, v := range values {
+		c[v/64] |= 1 << (v % 64)
+	}
+	return &c
+}
+
+// arrayContainer is a sorted slice of values, used while cardinality is low.
+type arrayContainer []uint16
+
+func (c arrayContainer) test(v uint16) bool {
+	i := sort.Search(len(c), func(i int) bool { return c[i] >= v })
+	return i < len(c) && c[i] == v
+}
+
+func (c arrayContainer) count() int { return len(c) }
+
+func (c arrayContainer) clone() container {
+	nc := make(arrayContainer, len(c))
+	copy(nc, c)
+	return nc
+}
+
+func (c arrayContainer) forEach(fn func(uint16)) {
+	for _, v := range c {
+		fn(v)
+	}
+}
+
+func (c arrayContainer) first(from uint16) (uint16, bool) {
+	i := sort.Search(len(c), func(i int) bool { return c[i] >= from })
+	if i < len(c) {
+		return c[i], true
+	}
+	return 0, false
+}
+
+func (c arrayContainer) firstClear(from uint16) (uint16, bool) {
+	v := from
+	i := sort.Search(len(c), func(i int) bool { return c[i] >= from })
+	for i < len(c) && c[i] == v {
+		if v == 0xffff {
+			return 0, false
+		}
+		i++
+		v++
+	}
+	return v, true
+}
+
+func (c arrayContainer) set(v uint16) container {
+	i := sort.Search(len(c), func(i int) bool { return c[i] >= v })
+	if i < len(c) && c[i] == v {
+		return c
+	}
+	c = append(c, 0)
+	copy(c[i+1:], c[i:])
+	c[i] = v
+	return optimize(c)
+}
+
+func (c arrayContainer) clear(v uint16) container {
+	i := sort.Search(len(c), func(i int) bool { return c[i] >= v })
+	if i >= len(c) || c[i] != v {
+		return c
+	}
+	c = append(c[:i], c[i+1:]...)
+	return optimize(c)
+}
+
+// bitmapContainer is a fixed 1024*uint64 = 8 KiB bitmap, used once
+// cardinality makes an array container more expensive.
+type bitmapContainer [bitmapWords]uint64
+
+func (c *bitmapContainer) test(v uint16) bool {
+	return c[v/64]&(1<<(v%64)) != 0
+}
+
+func (c *bitmapContainer) count() int {
+	total := 0
+	for _, w := range c {
+		total += bits.OnesCount64(w)
+	}
+	return total
+}
+
+func (c *bitmapContainer) clone() container {
+	nc := *c
+	return &nc
+}
+
+func (c *bitmapContainer) forEach(fn func(uint16)) {
+	for i, w := range c {
+		for w != 0 {
+			b := bits.TrailingZeros64(w)
+			fn(uint16(i*64 + b))
+			w &= w - 1
+		}
+	}
+}
+
+func (c *bitmapContainer) first(from uint16) (uint16, bool) {
+	word := int(from) / 64
+	if word < bitmapWords {
+		w := c[word] &^ (1<<(uint(from)%64) - 1)
+		if w != 0 {
+			return uint16(word*64 + bits.TrailingZeros64(w)), true
+		}
+		word++
+	}
+	for ; word < bitmapWords; word++ {
+		if c[word] != 0 {
+			return uint16(word*64 + bits.TrailingZeros64(c[word])), true
+		}
+	}
+	return 0, false
+}
+
+func (c *bitmapContainer) firstClear(from uint16) (uint16, bool) {
+	word := int(from) / 64
+	if word < bitmapWords {
+		w := ^c[word] &^ (1<<(uint(from)%64) - 1)
+		if w != 0 {
+			return uint16(word*64 + bits.TrailingZeros64(w)), true
+		}
+		word++
+	}
+	for ; word < bitmapWords; word++ {
+		if c[word] != ^uint64(0) {
+			return uint16(word*64 + bits.TrailingZeros64(^c[word])), true
+		}
+	}
+	return 0, false
+}
+
+func (c *bitmapContainer) set(v uint16) container {
+	nc := *c
+	nc[v/64] |= 1 << (v % 64)
+	return optimize(&nc)
+}
+
+func (c *bitmapContainer) clear(v uint16) container {
+	nc := *c
+	nc[v/64] &^= 1 << (v % 64)
+	return optimize(&nc)
+}
+
+// runContainer is a sorted slice of non-overlapping, non-adjacent runs,
+// used when the values form long consecutive stretches.
+type runContainer []run
+
+func (c runContainer) test(v uint16) bool {
+	i := sort.Search(len(c), func(i int) bool { return c[i].start > v })
+	if i == 0 {
+		return false
+	}
+	r := c[i-1]
+	return v >= r.start && uint32(v) <= uint32(r.start)+uint32(r.length)-1
+}
+
+func (c runContainer) count() int {
+	total := 0
+	for _, r := range c {
+		total += int(r.length)
+	}
+	return total
+}
+
+func (c runContainer) clone() container {
+	nc := make(runContainer, len(c))
+	copy(nc, c)
+	return nc
+}
+
+func (c runContainer) forEach(fn func(uint16)) {
+	for _, r := range c {
+		for i := uint32(0); i < uint32(r.length); i++ {
+			fn(uint16(uint32(r.start) + i))
+		}
+	}
+}
+
+func (c runContainer) first(from uint16) (uint16, bool) {
+	i := sort.Search(len(c), func(i int) bool { return uint32(c[i].start)+uint32(c[i].length) > uint32(from) })
+	if i >= len(c) {
+		return 0, false
+	}
+	if c[i].start >= from {
+		return c[i].start, true
+	}
+	return from, true
+}
+
+func (c runContainer) firstClear(from uint16) (uint16, bool) {
+	v := uint32(from)
+	for _, r := range c {
+		if v < uint32(r.start) {
+			return uint16(v), true
+		}
+		if v <= uint32(r.start)+uint32(r.length)-1 {
+			v = uint32(r.start) + uint32(r.length)
+		}
+	}
+	if v > 0xffff {
+		return 0, false
+	}
+	return uint16(v), true
+}
+
+func (c runContainer) set(v uint16) container {
+	if c.test(v) {
+		return c
+	}
+	nc := make(runContainer, len(c), len(c)+1)
+	copy(nc, c)
+	nc = append(nc, run{start: v, length: 1})
+	sort.Slice(nc, func(i, j int) bool { return nc[i].start < nc[j].start })
+	return optimize(coalesceRuns(nc))
+}
+
+func (c runContainer) clear(v uint16) container {
+	i := sort.Search(len(c), func(i int) bool { return c[i].start > v })
+	if i == 0 {
+		return c
+	}
+	r := c[i-1]
+	if v < r.start || uint32(v) > uint32(r.start)+uint32(r.length)-1 {
+		return c
+	}
+	nc := make(runContainer, 0, len(c)+1)
+	nc = append(nc, c[:i-1]...)
+	if v > r.start {
+		nc = append(nc, run{start: r.start, length: uint32(v - r.start)})
+	}
+	if uint32(v) < uint32(r.start)+r.length-1 {
+		nc = append(nc, run{start: v + 1, length: r.length - uint32(v-r.start) - 1})
+	}
+	nc = append(nc, c[i:]...)
+	return optimize(nc)
+}
+
+// coalesceRuns merges adjacent runs in a start-sorted runContainer.
+func coalesceRuns(c runContainer) runContainer {
+	if len(c) == 0 {
+		return c
+	}
+	out := c[:1]
+	for _, r := range c[1:] {
+		last := &out[len(out)-1]
+		if uint32(last.start)+uint32(last.length) == uint32(r.start) {
+			last.length += r.length
+		} else {
+			out = append(out, r)
+		}
+	}
+	return out
+}
@@ -0,0 +1,198 @@
+package sparse
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// values returns the values of c in ascending order.
+func values(c container) []uint16 {
+	var vs []uint16
+	c.forEach(func(v uint16) { vs = append(vs, v) })
+	return vs
+}
+
+func TestOptimizeArrayStaysArrayWhenSmall(t *testing.T) {
+	c := container(arrayContainer(nil))
+	for _, v := range []uint16{5, 1, 3} {
+		c = c.set(v)
+	}
+	if _, ok := c.(arrayContainer); !ok {
+		t.Fatalf("expected arrayContainer, got %T", c)
+	}
+	if got := values(c); !equalUint16(got, []uint16{1, 3, 5}) {
+		t.Fatalf("values = %v, want [1 3 5]", got)
+	}
+}
+
+func TestOptimizePromotesArrayToBitmap(t *testing.T) {
+	// arrayMaxCardinality+1 scattered (non-consecutive) values are cheaper
+	// as a bitmap (fixed 8 KiB) than as an array (2 bytes each) and don't
+	// run-length-encode, so optimize must promote to a bitmapContainer.
+	var c container = arrayContainer(nil)
+	for i := 0; i < arrayMaxCardinality+1; i++ {
+		c = c.set(uint16(i * 2))
+	}
+	bc, ok := c.(*bitmapContainer)
+	if !ok {
+		t.Fatalf("expected *bitmapContainer, got %T", c)
+	}
+	if n := bc.count(); n != arrayMaxCardinality+1 {
+		t.Fatalf("count = %d, want %d", n, arrayMaxCardinality+1)
+	}
+	for i := 0; i < arrayMaxCardinality+1; i++ {
+		if !bc.test(uint16(i * 2)) {
+			t.Fatalf("missing value %d after promotion", i*2)
+		}
+	}
+}
+
+func TestOptimizePromotesToRunContainer(t *testing.T) {
+	// A long consecutive run costs 4 bytes total as a run container, far
+	// cheaper than either an array or a bitmap representation.
+	var c container = arrayContainer(nil)
+	for i := 0; i < 10000; i++ {
+		c = c.set(uint16(i))
+	}
+	rc, ok := c.(runContainer)
+	if !ok {
+		t.Fatalf("expected runContainer, got %T", c)
+	}
+	if len(rc) != 1 || rc[0] != (run{start: 0, length: 10000}) {
+		t.Fatalf("runs = %v, want a single run covering [0,10000)", rc)
+	}
+}
+
+func TestOptimizeDemotesBitmapToArrayOnClear(t *testing.T) {
+	var c container = arrayContainer(nil)
+	for i := 0; i < arrayMaxCardinality+1; i++ {
+		c = c.set(uint16(i * 2))
+	}
+	if _, ok := c.(*bitmapContainer); !ok {
+		t.Fatalf("setup: expected *bitmapContainer, got %T", c)
+	}
+	// Clearing back down below the array/bitmap crossover should demote.
+	for i := 1; i < arrayMaxCardinality; i++ {
+		c = c.clear(uint16(i * 2))
+	}
+	if _, ok := c.(arrayContainer); !ok {
+		t.Fatalf("expected demotion to arrayContainer, got %T", c)
+	}
+}
+
+func TestRunContainerClearSplitsMiddle(t *testing.T) {
+	values0 := make([]uint16, 100)
+	for i := range values0 {
+		values0[i] = uint16(i)
+	}
+	var c container = runFromValues(values0)
+	c = c.clear(50)
+	got := values(c)
+	want := make([]uint16, 0, 99)
+	for i := 0; i < 100; i++ {
+		if i != 50 {
+			want = append(want, uint16(i))
+		}
+	}
+	if !equalUint16(got, want) {
+		t.Fatalf("values = %v, want %v", got, want)
+	}
+	rc, ok := c.(runContainer)
+	if !ok {
+		t.Fatalf("expected runContainer, got %T", c)
+	}
+	if len(rc) != 2 {
+		t.Fatalf("expected clearing the middle of a run to split it into two runs, got %v", rc)
+	}
+}
+
+func TestRunContainerSetCoalescesAdjacent(t *testing.T) {
+	var c container = runFromValues([]uint16{0, 1, 2, 5, 6, 7})
+	c = c.set(3)
+	c = c.set(4)
+	rc, ok := c.(runContainer)
+	if !ok {
+		t.Fatalf("expected runContainer, got %T", c)
+	}
+	if len(rc) != 1 || rc[0] != (run{start: 0, length: 8}) {
+		t.Fatalf("runs = %v, want a single coalesced run [0,8)", rc)
+	}
+}
+
+func TestContainerFirstAndFirstClearAgreeAcrossKinds(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	want := make(map[uint16]bool)
+	var c container = arrayContainer(nil)
+	for i := 0; i < 500; i++ {
+		v := uint16(rng.Intn(2000))
+		c = c.set(v)
+		want[v] = true
+	}
+
+	// Force the same logical set through all three representations and
+	// require first/firstClear to agree.
+	var vs []uint16
+	for v := range want {
+		vs = append(vs, v)
+	}
+	sortUint16(vs)
+	// arrayContainer and runContainer both rely on ascending order
+	// (bitmapContainer doesn't care), so build every representation from
+	// the sorted slice.
+	reps := []container{arrayContainer(vs), bitmapFromValues(vs), runFromValues(vs)}
+
+	for from := uint16(0); from < 2005; from += 37 {
+		var refFirst uint16
+		var refFirstOK bool
+		for v := from; ; v++ {
+			if want[v] {
+				refFirst, refFirstOK = v, true
+				break
+			}
+			if v == 0xffff {
+				break
+			}
+		}
+		var refClear uint16
+		var refClearOK bool
+		for v := from; ; v++ {
+			if !want[v] {
+				refClear, refClearOK = v, true
+				break
+			}
+			if v == 0xffff {
+				break
+			}
+		}
+		for _, rep := range reps {
+			gotFirst, gotFirstOK := rep.first(from)
+			if gotFirstOK != refFirstOK || (refFirstOK && gotFirst != refFirst) {
+				t.Fatalf("%T.first(%d) = (%d,%v), want (%d,%v)", rep, from, gotFirst, gotFirstOK, refFirst, refFirstOK)
+			}
+			gotClear, gotClearOK := rep.firstClear(from)
+			if gotClearOK != refClearOK || (refClearOK && gotClear != refClear) {
+				t.Fatalf("%T.firstClear(%d) = (%d,%v), want (%d,%v)", rep, from, gotClear, gotClearOK, refClear, refClearOK)
+			}
+		}
+	}
+}
+
+func equalUint16(a, b []uint16) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func sortUint16(s []uint16) {
+	for i := 1; i < len(s); i++ {
+		for j := i; j > 0 && s[j-1] > s[j]; j-- {
+			s[j-1], s[j] = s[j], s[j-1]
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package sparse
+
+import "sort"
+
+// combine merges two containers value-by-value, keeping a value v in the
+// result iff keep(v is in a, v is in b) is true.
+func combine(a, b container, keep func(inA, inB bool) bool) container {
+	present := make(map[uint16][2]bool, a.count()+b.count())
+	a.forEach(func(v uint16) {
+		e := present[v]
+		e[0] = true
+		present[v] = e
+	})
+	b.forEach(func(v uint16) {
+		e := present[v]
+		e[1] = true
+		present[v] = e
+	})
+	values := make([]uint16, 0, len(present))
+	for v, e := range present {
+		if keep(e[0], e[1]) {
+			values = append(values, v)
+		}
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+	return optimize(arrayContainer(values))
+}
+
+// mergeWith walks s's and other's containers in key order, combining
+// containers present in both with both, and passing containers present in
+// only one side through onlyA/onlyB (nil means "drop").
+func (s *SparseBitfield) mergeWith(other *SparseBitfield, both func(a, b container) container, onlyA, onlyB func(c container) container) *SparseBitfield {
+	result := &SparseBitfield{}
+	i, j := 0, 0
+	for i < len(s.containers) || j < len(other.containers) {
+		switch {
+		case j >= len(other.containers) || (i < len(s.containers) && s.containers[i].key < other.containers[j].key):
+			if onlyA != nil {
+				if c := onlyA(s.containers[i].c); c.count() > 0 {
+					result.containers = append(result.containers, entry{s.containers[i].key, c})
+				}
+			}
+			i++
+		case i >= len(s.containers) || other.containers[j].key < s.containers[i].key:
+			if onlyB != nil {
+				if c := onlyB(other.containers[j].c); c.count() > 0 {
+					result.containers = append(result.containers, entry{other.containers[j].key, c})
+				}
+			}
+			j++
+		default:
+			if c := both(s.containers[i].c, other.containers[j].c); c.count() > 0 {
+				result.containers = append(result.containers, entry{s.containers[i].key, c})
+			}
+			i++
+			j++
+		}
+	}
+	return result
+}
+
+func cloneContainer(c container) container { return c.clone() }
+
+// Union returns a new SparseBitfield holding every bit set in s or other.
+func (s *SparseBitfield) Union(other *SparseBitfield) *SparseBitfield {
+	both := func(a, b container) container {
+		return combine(a, b, func(inA, inB bool) bool { return inA || inB })
+	}
+	return s.mergeWith(other, both, cloneContainer, cloneContainer)
+}
+
+// Intersection returns a new SparseBitfield holding every bit set in both s
+// and other.
+func (s *SparseBitfield) Intersection(other *SparseBitfield) *SparseBitfield {
+	both := func(a, b container) container {
+		return combine(a, b, func(inA, inB bool) bool { return inA && inB })
+	}
+	return s.mergeWith(other, both, nil, nil)
+}
+
+// Difference returns a new SparseBitfield holding the bits set in s but not
+// in other.
+func (s *SparseBitfield) Difference(other *SparseBitfield) *SparseBitfield {
+	both := func(a, b container) container {
+		return combine(a, b, func(inA, inB bool) bool { return inA && !inB })
+	}
+	return s.mergeWith(other, both, cloneContainer, nil)
+}
@@ -0,0 +1,213 @@
+package sparse
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// serialCookie is the Roaring "no run container" serial cookie. Run
+// containers are written as whichever of array or bitmap is smaller, since
+// that cookie has no run encoding of its own; this keeps the wire format
+// a strict subset of the portable Roaring spec that any implementation
+// can read.
+const serialCookie = 12346
+
+// WriteTo writes s to w in the Roaring portable serialization format:
+// cookie, container count, then per-container (key, cardinality-1)
+// descriptors, then per-container payload offsets, then the payloads
+// themselves, all little-endian.
+func (s *SparseBitfield) WriteTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: w}
+
+	if err := writeUint32(cw, serialCookie); err != nil {
+		return cw.n, err
+	}
+	if err := writeUint32(cw, uint32(len(s.containers))); err != nil {
+		return cw.n, err
+	}
+
+	payloads := make([][]uint16, len(s.containers))        // non-nil for array payloads
+	bitmaps := make([]*bitmapContainer, len(s.containers)) // non-nil for bitmap payloads
+	for i, e := range s.containers {
+		n := e.c.count()
+		if err := writeUint16(cw, e.key); err != nil {
+			return cw.n, err
+		}
+		if err := writeUint16(cw, uint16(n-1)); err != nil {
+			return cw.n, err
+		}
+		if bc, ok := e.c.(*bitmapContainer); ok {
+			bitmaps[i] = bc
+			continue
+		}
+		values := make([]uint16, 0, n)
+		e.c.forEach(func(v uint16) { values = append(values, v) })
+		if len(values) > arrayMaxCardinality {
+			bitmaps[i] = bitmapFromValues(values)
+		} else {
+			payloads[i] = values
+		}
+	}
+
+	offset := uint32(8 + 4*len(s.containers) + 4*len(s.containers))
+	for i := range s.containers {
+		if err := writeUint32(cw, offset); err != nil {
+			return cw.n, err
+		}
+		if bitmaps[i] != nil {
+			offset += bitmapWords * 8
+		} else {
+			offset += uint32(len(payloads[i])) * 2
+		}
+	}
+
+	for i := range s.containers {
+		if bitmaps[i] != nil {
+			for _, word := range bitmaps[i] {
+				if err := writeUint64(cw, word); err != nil {
+					return cw.n, err
+				}
+			}
+			continue
+		}
+		for _, v := range payloads[i] {
+			if err := writeUint16(cw, v); err != nil {
+				return cw.n, err
+			}
+		}
+	}
+	return cw.n, nil
+}
+
+// ReadFrom reads a SparseBitfield previously written by WriteTo, replacing
+// s's contents.
+func (s *SparseBitfield) ReadFrom(r io.Reader) (int64, error) {
+	cr := &countingReader{r: r}
+
+	cookie, err := readUint32(cr)
+	if err != nil {
+		return cr.n, err
+	}
+	if cookie != serialCookie {
+		return cr.n, fmt.Errorf("bitfield/sparse: unsupported cookie %d", cookie)
+	}
+	size, err := readUint32(cr)
+	if err != nil {
+		return cr.n, err
+	}
+
+	keys := make([]uint16, size)
+	cards := make([]int, size)
+	for i := range keys {
+		k, err := readUint16(cr)
+		if err != nil {
+			return cr.n, err
+		}
+		c, err := readUint16(cr)
+		if err != nil {
+			return cr.n, err
+		}
+		keys[i] = k
+		cards[i] = int(c) + 1
+	}
+	for i := uint32(0); i < size; i++ { // offsets: containers follow sequentially, so skip
+		if _, err := readUint32(cr); err != nil {
+			return cr.n, err
+		}
+	}
+
+	containers := make([]entry, size)
+	for i := range containers {
+		if cards[i] > arrayMaxCardinality {
+			var bc bitmapContainer
+			for w := 0; w < bitmapWords; w++ {
+				v, err := readUint64(cr)
+				if err != nil {
+					return cr.n, err
+				}
+				bc[w] = v
+			}
+			containers[i] = entry{keys[i], &bc}
+			continue
+		}
+		values := make([]uint16, cards[i])
+		for j := range values {
+			v, err := readUint16(cr)
+			if err != nil {
+				return cr.n, err
+			}
+			values[j] = v
+		}
+		containers[i] = entry{keys[i], arrayContainer(values)}
+	}
+	s.containers = containers
+	return cr.n, nil
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func writeUint16(w io.Writer, v uint16) error {
+	var b [2]byte
+	binary.LittleEndian.PutUint16(b[:], v)
+	_, err := w.Write(b[:])
+	return err
+}
+
+func writeUint32(w io.Writer, v uint32) error {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], v)
+	_, err := w.Write(b[:])
+	return err
+}
+
+func writeUint64(w io.Writer, v uint64) error {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], v)
+	_, err := w.Write(b[:])
+	return err
+}
+
+func readUint16(r io.Reader) (uint16, error) {
+	var b [2]byte
+	if _, err := io.ReadFull(r, b[:]); err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint16(b[:]), nil
+}
+
+func readUint32(r io.Reader) (uint32, error) {
+	var b [4]byte
+	if _, err := io.ReadFull(r, b[:]); err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint32(b[:]), nil
+}
+
+func readUint64(r io.Reader) (uint64, error) {
+	var b [8]byte
+	if _, err := io.ReadFull(r, b[:]); err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint64(b[:]), nil
+}
@@ -0,0 +1,168 @@
+package sparse
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math/rand"
+	"testing"
+)
+
+func TestSerializeRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	s := New()
+	for i := 0; i < 20000; i++ {
+		s.Set(uint32(rng.Intn(1 << 22)))
+	}
+	// Also exercise a run-shaped container and a half-dense bitmap
+	// container, so the round trip covers all three container kinds (the
+	// run container is written as a bitmap payload, since the format has
+	// only array and bitmap payloads).
+	for i := 0; i < 10000; i++ {
+		s.Set(uint32(1<<24) + uint32(i))
+	}
+	for i := 0; i < 60000; i += 2 {
+		s.Set(uint32(1<<25) + uint32(i))
+	}
+
+	var buf bytes.Buffer
+	if _, err := s.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo: %v", err)
+	}
+
+	got := New()
+	if _, err := got.ReadFrom(bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatalf("ReadFrom: %v", err)
+	}
+	if got.Count() != s.Count() {
+		t.Fatalf("Count after round trip = %d, want %d", got.Count(), s.Count())
+	}
+	for it := s.Iterator(); ; {
+		v, ok := it.Next()
+		if !ok {
+			break
+		}
+		if !got.Test(v) {
+			t.Fatalf("bit %d missing after round trip", v)
+		}
+	}
+}
+
+// The tests below check the wire format against the Roaring "portable"
+// serialization spec directly -- byte-for-byte, using encoding/binary
+// rather than this package's own (de)serialization code -- standing in
+// for a genuine cross-implementation interop fixture. The sandbox this
+// backlog is implemented in has no network access and can't vendor a
+// reference Roaring implementation to round-trip against; a buffer built
+// straight from the published cookie/offset/payload layout is the
+// closest available substitute for "decode a buffer produced by a
+// reference encoder, and vice versa".
+
+// buildPortableFixture hand-assembles a Roaring portable-format buffer
+// with two containers: key 0 as a small array ({1,3,5}), and key 1 as a
+// bitmap with cardinality 4097 (forced above arrayMaxCardinality so a
+// portable reader that chooses array-vs-bitmap purely from cardinality,
+// as ReadFrom does, agrees with one that reads the actual payload size).
+func buildPortableFixture() []byte {
+	arrayValues := []uint16{1, 3, 5}
+
+	var bitmap [bitmapWords]uint64
+	for w := 0; w < 64; w++ {
+		bitmap[w] = ^uint64(0)
+	}
+	bitmap[64] |= 1 // bumps cardinality from 4096 to 4097
+	bitmapCard := 4097
+
+	var buf bytes.Buffer
+	le := binary.LittleEndian
+	put32 := func(v uint32) { var b [4]byte; le.PutUint32(b[:], v); buf.Write(b[:]) }
+	put16 := func(v uint16) { var b [2]byte; le.PutUint16(b[:], v); buf.Write(b[:]) }
+	put64 := func(v uint64) { var b [8]byte; le.PutUint64(b[:], v); buf.Write(b[:]) }
+
+	put32(serialCookie)
+	put32(2) // container count
+
+	put16(0) // key 0
+	put16(uint16(len(arrayValues) - 1))
+	put16(1) // key 1
+	put16(uint16(bitmapCard - 1))
+
+	offset0 := uint32(8 + 2*4 + 2*4)
+	offset1 := offset0 + uint32(len(arrayValues))*2
+	put32(offset0)
+	put32(offset1)
+
+	for _, v := range arrayValues {
+		put16(v)
+	}
+	for _, w := range bitmap {
+		put64(w)
+	}
+	return buf.Bytes()
+}
+
+func TestInteropDecodePortableFixture(t *testing.T) {
+	s := New()
+	if _, err := s.ReadFrom(bytes.NewReader(buildPortableFixture())); err != nil {
+		t.Fatalf("ReadFrom: %v", err)
+	}
+	for _, v := range []uint32{1, 3, 5} {
+		if !s.Test(v) {
+			t.Fatalf("missing array value %d", v)
+		}
+	}
+	for i := 0; i < 64; i++ {
+		v := uint32(1)<<16 | uint32(i*64)
+		if !s.Test(v) {
+			t.Fatalf("missing bitmap value %d", v)
+		}
+	}
+	if !s.Test(1<<16 | 64*64) {
+		t.Fatal("missing the extra bitmap bit that pushes cardinality to 4097")
+	}
+	if s.Count() != 3+4097 {
+		t.Fatalf("Count = %d, want %d", s.Count(), 3+4097)
+	}
+}
+
+func TestInteropEncodeMatchesPortableLayout(t *testing.T) {
+	s := New()
+	for _, v := range []uint32{1, 3, 5} {
+		s.Set(v)
+	}
+	for i := 0; i < 4096; i++ {
+		s.Set(uint32(1)<<16 | uint32(i))
+	}
+	s.Set(1<<16 | 4096) // bumps cardinality to 4097, above arrayMaxCardinality
+
+	var buf bytes.Buffer
+	if _, err := s.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo: %v", err)
+	}
+
+	r := bytes.NewReader(buf.Bytes())
+	le := binary.LittleEndian
+	read32 := func() uint32 { var b [4]byte; r.Read(b[:]); return le.Uint32(b[:]) }
+	read16 := func() uint16 { var b [2]byte; r.Read(b[:]); return le.Uint16(b[:]) }
+
+	if cookie := read32(); cookie != serialCookie {
+		t.Fatalf("cookie = %d, want %d", cookie, serialCookie)
+	}
+	if n := read32(); n != 2 {
+		t.Fatalf("container count = %d, want 2", n)
+	}
+	if key, card := read16(), read16(); key != 0 || card != 2 {
+		t.Fatalf("descriptor 0 = (key=%d,card-1=%d), want (0,2)", key, card)
+	}
+	if key, card := read16(), read16(); key != 1 || card != 4096 {
+		t.Fatalf("descriptor 1 = (key=%d,card-1=%d), want (1,4096)", key, card)
+	}
+	// Offsets follow; payload layout (array then bitmap words) is already
+	// exercised byte-for-byte by the decode direction above, so just
+	// confirm the stream is the length the portable spec predicts:
+	// header(8) + 2 descriptors(8) + 2 offsets(8) + array payload(6) +
+	// bitmap payload(1024*8).
+	wantLen := 8 + 2*4 + 2*4 + len([]uint16{1, 3, 5})*2 + bitmapWords*8
+	if buf.Len() != wantLen {
+		t.Fatalf("encoded length = %d, want %d", buf.Len(), wantLen)
+	}
+}
@@ -0,0 +1,169 @@
+package sparse
+
+import (
+	"sort"
+
+	"github.com/cenkalti/bitfield"
+)
+
+// entry pairs a container with the high 16 bits of the indices it holds.
+type entry struct {
+	key uint16
+	c   container
+}
+
+// SparseBitfield is a compressed bitmap over the 32-bit index space,
+// suitable for piece sets that are very large but mostly empty or mostly
+// full. See the package doc for the in-memory container representations.
+type SparseBitfield struct {
+	containers []entry // sorted by key
+}
+
+// New returns a new, empty SparseBitfield.
+func New() *SparseBitfield {
+	return &SparseBitfield{}
+}
+
+func split(i uint32) (hi, lo uint16) { return uint16(i >> 16), uint16(i) }
+
+func join(hi, lo uint16) uint32 { return uint32(hi)<<16 | uint32(lo) }
+
+// find returns the index of the first container with key >= hi.
+func (s *SparseBitfield) find(hi uint16) int {
+	return sort.Search(len(s.containers), func(i int) bool { return s.containers[i].key >= hi })
+}
+
+// Set sets bit i.
+func (s *SparseBitfield) Set(i uint32) {
+	hi, lo := split(i)
+	idx := s.find(hi)
+	if idx < len(s.containers) && s.containers[idx].key == hi {
+		s.containers[idx].c = s.containers[idx].c.set(lo)
+		return
+	}
+	s.containers = append(s.containers, entry{})
+	copy(s.containers[idx+1:], s.containers[idx:])
+	s.containers[idx] = entry{hi, arrayContainer(nil).set(lo)}
+}
+
+// Clear clears bit i.
+func (s *SparseBitfield) Clear(i uint32) {
+	hi, lo := split(i)
+	idx := s.find(hi)
+	if idx >= len(s.containers) || s.containers[idx].key != hi {
+		return
+	}
+	c := s.containers[idx].c.clear(lo)
+	if c.count() == 0 {
+		s.containers = append(s.containers[:idx], s.containers[idx+1:]...)
+		return
+	}
+	s.containers[idx].c = c
+}
+
+// Test reports whether bit i is set.
+func (s *SparseBitfield) Test(i uint32) bool {
+	hi, lo := split(i)
+	idx := s.find(hi)
+	if idx >= len(s.containers) || s.containers[idx].key != hi {
+		return false
+	}
+	return s.containers[idx].c.test(lo)
+}
+
+// Count returns the total number of set bits.
+func (s *SparseBitfield) Count() uint32 {
+	var total uint32
+	for _, e := range s.containers {
+		total += uint32(e.c.count())
+	}
+	return total
+}
+
+// FirstSet returns the index of the first set bit starting from start, if any.
+func (s *SparseBitfield) FirstSet(start uint32) (uint32, bool) {
+	hi, lo := split(start)
+	idx := s.find(hi)
+	if idx < len(s.containers) && s.containers[idx].key == hi {
+		if v, ok := s.containers[idx].c.first(lo); ok {
+			return join(hi, v), true
+		}
+		idx++
+	}
+	if idx < len(s.containers) {
+		e := s.containers[idx]
+		v, _ := e.c.first(0)
+		return join(e.key, v), true
+	}
+	return 0, false
+}
+
+// FirstClear returns the index of the first bit that is not set starting
+// from start, if any. Since the index space is 32 bits wide and a
+// SparseBitfield has no fixed length, this is almost always start itself.
+func (s *SparseBitfield) FirstClear(start uint32) (uint32, bool) {
+	hi, lo := split(start)
+	idx := s.find(hi)
+	if idx >= len(s.containers) || s.containers[idx].key != hi {
+		return start, true
+	}
+	if v, ok := s.containers[idx].c.firstClear(lo); ok {
+		return join(hi, v), true
+	}
+	if hi == 0xffff {
+		return 0, false
+	}
+	return s.FirstClear(join(hi+1, 0))
+}
+
+// ToBitfield converts s into a dense *bitfield.Bitfield of the given
+// length. Panics if s has any bit set at or past length.
+func (s *SparseBitfield) ToBitfield(length uint32) *bitfield.Bitfield {
+	bf := bitfield.New(length)
+	for _, e := range s.containers {
+		e.c.forEach(func(lo uint16) {
+			bf.Set(join(e.key, lo))
+		})
+	}
+	return bf
+}
+
+// FromBitfield returns a SparseBitfield containing the set bits of bf.
+func FromBitfield(bf *bitfield.Bitfield) *SparseBitfield {
+	s := New()
+	for i, ok := bf.FirstSet(0); ok; i, ok = bf.FirstSet(i + 1) {
+		s.Set(i)
+	}
+	return s
+}
+
+// Iterator yields the set indices of a SparseBitfield in ascending order.
+type Iterator struct {
+	s    *SparseBitfield
+	next uint32
+	done bool
+}
+
+// Iterator returns a new Iterator over s's set bits.
+func (s *SparseBitfield) Iterator() *Iterator {
+	return &Iterator{s: s}
+}
+
+// Next returns the next set index, in ascending order, or false once
+// exhausted.
+func (it *Iterator) Next() (uint32, bool) {
+	if it.done {
+		return 0, false
+	}
+	v, ok := it.s.FirstSet(it.next)
+	if !ok {
+		it.done = true
+		return 0, false
+	}
+	if v == 0xffffffff {
+		it.done = true
+	} else {
+		it.next = v + 1
+	}
+	return v, true
+}
@@ -0,0 +1,137 @@
+package sparse
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/cenkalti/bitfield"
+)
+
+func TestSetClearTest(t *testing.T) {
+	s := New()
+	if s.Test(5) {
+		t.Fatal("Test on empty SparseBitfield returned true")
+	}
+	s.Set(5)
+	s.Set(1 << 20) // a different container's key
+	if !s.Test(5) || !s.Test(1<<20) {
+		t.Fatal("Test false for a bit that was Set")
+	}
+	if s.Count() != 2 {
+		t.Fatalf("Count = %d, want 2", s.Count())
+	}
+	s.Clear(5)
+	if s.Test(5) {
+		t.Fatal("Test true after Clear")
+	}
+	if s.Count() != 1 {
+		t.Fatalf("Count = %d, want 1", s.Count())
+	}
+	// Clearing the last bit in a container should drop the container
+	// entirely rather than leave an empty one behind.
+	s.Clear(1 << 20)
+	if len(s.containers) != 0 {
+		t.Fatalf("containers = %v, want none after clearing all bits", s.containers)
+	}
+}
+
+func TestFirstSetFirstClearAcrossContainers(t *testing.T) {
+	s := New()
+	s.Set(10)
+	s.Set(1 << 17)
+	if v, ok := s.FirstSet(0); !ok || v != 10 {
+		t.Fatalf("FirstSet(0) = (%d,%v), want (10,true)", v, ok)
+	}
+	if v, ok := s.FirstSet(11); !ok || v != 1<<17 {
+		t.Fatalf("FirstSet(11) = (%d,%v), want (%d,true)", v, ok, 1<<17)
+	}
+	if v, ok := s.FirstClear(10); !ok || v != 11 {
+		t.Fatalf("FirstClear(10) = (%d,%v), want (11,true)", v, ok)
+	}
+	// FirstClear must cross a fully-occupied container's key boundary.
+	full := New()
+	for i := 0; i < 1<<16; i++ {
+		full.Set(uint32(i))
+	}
+	if v, ok := full.FirstClear(0); !ok || v != 1<<16 {
+		t.Fatalf("FirstClear(0) = (%d,%v), want (%d,true)", v, ok, 1<<16)
+	}
+}
+
+func TestIteratorYieldsAscending(t *testing.T) {
+	s := New()
+	want := []uint32{3, 70000, 70001, 1 << 31}
+	for _, v := range want {
+		s.Set(v)
+	}
+	var got []uint32
+	it := s.Iterator()
+	for {
+		v, ok := it.Next()
+		if !ok {
+			break
+		}
+		got = append(got, v)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestToFromBitfieldRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	const length = 1 << 14
+	bf := bitfield.New(length)
+	for i := 0; i < 1000; i++ {
+		bf.Set(uint32(rng.Intn(length)))
+	}
+	s := FromBitfield(bf)
+	got := s.ToBitfield(length)
+	if !got.Equals(bf) {
+		t.Fatal("ToBitfield(FromBitfield(bf)) != bf")
+	}
+}
+
+func TestUnionIntersectionDifference(t *testing.T) {
+	a := New()
+	b := New()
+	for _, v := range []uint32{1, 2, 3, 1 << 20} {
+		a.Set(v)
+	}
+	for _, v := range []uint32{2, 3, 4, 1 << 20} {
+		b.Set(v)
+	}
+
+	union := a.Union(b)
+	wantUnion := []uint32{1, 2, 3, 4, 1 << 20}
+	checkSparseContains(t, union, wantUnion)
+
+	inter := a.Intersection(b)
+	wantInter := []uint32{2, 3, 1 << 20}
+	checkSparseContains(t, inter, wantInter)
+
+	diff := a.Difference(b)
+	wantDiff := []uint32{1}
+	checkSparseContains(t, diff, wantDiff)
+
+	// Original operands must be untouched.
+	checkSparseContains(t, a, []uint32{1, 2, 3, 1 << 20})
+	checkSparseContains(t, b, []uint32{2, 3, 4, 1 << 20})
+}
+
+func checkSparseContains(t *testing.T, s *SparseBitfield, want []uint32) {
+	t.Helper()
+	if got := s.Count(); got != uint32(len(want)) {
+		t.Fatalf("Count = %d, want %d", got, len(want))
+	}
+	for _, v := range want {
+		if !s.Test(v) {
+			t.Fatalf("missing expected bit %d", v)
+		}
+	}
+}